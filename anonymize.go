@@ -0,0 +1,131 @@
+package roflparser
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// anonymizedFields relaciona cada campo de StatsJSON con datos personales con el tipo de seudónimo
+// que lo sustituye. NAME y RIOT_ID_GAME_NAME comparten tipo para que un mismo nombre dé el mismo seudónimo.
+var anonymizedFields = map[string]string{
+	"PUUID":             "puuid",
+	"SUMMONER_ID":       "summonerId",
+	"NAME":              "name",
+	"RIOT_ID_GAME_NAME": "name",
+	"RIOT_ID_TAG_LINE":  "tagLine",
+}
+
+// Anonymize sustituye PUUID, SUMMONER_ID, NAME, RIOT_ID_GAME_NAME y RIOT_ID_TAG_LINE de cada jugador
+// en StatsJSON y Stats por seudónimos derivados con HMAC-SHA256 y salt. Con el mismo salt, un mismo
+// identificador da siempre el mismo seudónimo, también entre replays distintos; el resto de campos no se
+// modifica.
+//
+// Los chunks y keyframes también contienen los datos de los jugadores y se pueden descifrar con la
+// clave del payload header, así que Anonymize los descarta junto con el payload header (clave y
+// GameId, que permite consultar la partida) y pone la firma a cero. Un replay anonimizado solo
+// conserva la metadata; AnonymizeMetadata anonimiza la metadata sin tocar el payload.
+func Anonymize(r *model.Rofl, salt []byte) error {
+	if err := AnonymizeMetadata(r, salt); err != nil {
+		return err
+	}
+	r.PayloadHeader = model.PayloadHeader{}
+	r.Chunks = nil
+	r.Keyframes = nil
+	r.SetSource(nil, 0)
+	// El payload se descarta a propósito, así que da igual si se llegó a leer
+	r.PayloadSkipped = false
+	return nil
+}
+
+// AnonymizeMetadata sustituye los identificadores de StatsJSON como Anonymize y pone la firma a cero,
+// pero conserva el payload tal cual. Los chunks y keyframes siguen conteniendo los datos personales
+// de los jugadores, así que el resultado no debe compartirse como un replay anónimo.
+func AnonymizeMetadata(r *model.Rofl, salt []byte) error {
+	if len(salt) == 0 {
+		return errors.New("el salt no puede estar vacío")
+	}
+	r.Signature = [256]byte{}
+	if r.Metadata.StatsJSON == "" {
+		return nil
+	}
+	statsJson, err := anonymizeStatsJSON(r.Metadata.StatsJSON, salt)
+	if err != nil {
+		return err
+	}
+	r.Metadata.StatsJSON = statsJson
+	if r.Metadata.Stats != nil {
+		r.Metadata.Stats = nil
+		if err := json.Unmarshal([]byte(statsJson), &r.Metadata.Stats); err != nil {
+			return fmt.Errorf("%w: %v", ErrStatsMalformed, err)
+		}
+	}
+	// RawMetadata guarda el statsJson original: se sustituye por la metadata anonimizada
+	if len(r.RawMetadata) > 0 {
+		metaBytes, err := encodeMetadata(r)
+		if err != nil {
+			return err
+		}
+		r.RawMetadata = metaBytes
+	}
+	return nil
+}
+
+// anonymizeStatsJSON sustituye los identificadores de cada jugador conservando tal cual el resto de valores
+func anonymizeStatsJSON(statsJson string, salt []byte) (string, error) {
+	var players []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(statsJson), &players); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrStatsMalformed, err)
+	}
+	for idx, player := range players {
+		for key, kind := range anonymizedFields {
+			raw, ok := player[key]
+			if !ok {
+				continue
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return "", fmt.Errorf("%w: jugador %d, campo %s: %v", ErrStatsMalformed, idx, key, err)
+			}
+			if value == "" {
+				continue
+			}
+			player[key], _ = json.Marshal(pseudonym(salt, kind, value))
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(players); err != nil {
+		return "", fmt.Errorf("error serializando StatsJSON: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// pseudonym deriva el seudónimo de value con el formato propio de cada tipo de identificador
+func pseudonym(salt []byte, kind, value string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(kind + "\x00" + value))
+	sum := mac.Sum(nil)
+
+	switch kind {
+	case "puuid":
+		h := hex.EncodeToString(sum[:16])
+		return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	case "summonerId":
+		return fmt.Sprint(binary.BigEndian.Uint64(sum[:8]) % 1_000_000_000)
+	case "tagLine":
+		return strings.ToUpper(hex.EncodeToString(sum[:2]))
+	default:
+		return "Player-" + hex.EncodeToString(sum[:4])
+	}
+}
@@ -0,0 +1,136 @@
+package roflparser
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// Result es el resultado de parsear uno de los archivos de ParseMany o ParseDir
+type Result struct {
+	Path        string
+	Rofl        *model.Rofl
+	MetadataErr *model.MetadataValidationError
+	StatsErrs   []model.PlayerStatsValidationError
+	// Err es el error de parseo de este archivo; no detiene el resto del lote
+	Err error
+	// Done y Total indican el progreso: archivos terminados, incluido este, y archivos del lote
+	Done  int
+	Total int
+}
+
+// ParseMany parsea los archivos de paths en paralelo con workers goroutines (runtime.NumCPU() si
+// workers <= 0) y envía un Result por archivo al canal devuelto, en el orden en que terminan.
+// El canal se cierra cuando se han procesado todos o cuando se cancela ctx; tras la cancelación
+// no se parsean más archivos y los pendientes no producen resultado.
+func ParseMany(ctx context.Context, paths []string, workers int, opts ...Option) <-chan Result {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	metadataOnly := newConfig(opts).metadataOnly
+	jobs := make(chan string)
+	parsed := make(chan Result)
+	results := make(chan Result)
+
+	go func() {
+		defer close(jobs)
+		for _, path := range paths {
+			select {
+			case jobs <- path:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				r, metaErr, statsErrs, err := parseBatchFile(path, metadataOnly, opts)
+				res := Result{Path: path, Rofl: r, MetadataErr: metaErr, StatsErrs: statsErrs, Err: err}
+				select {
+				case parsed <- res:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(parsed)
+	}()
+
+	// Un único recolector numera los resultados para que Done crezca en el orden de llegada
+	go func() {
+		defer close(results)
+		done := 0
+		for res := range parsed {
+			done++
+			res.Done = done
+			res.Total = len(paths)
+			select {
+			case results <- res:
+			case <-ctx.Done():
+				for range parsed {
+				}
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// parseBatchFile parsea un archivo del lote. En modo solo metadata se lee con NewFromReaderAt, de
+// modo que de cada archivo solo se cargan la cabecera y la metadata; como el archivo se cierra al
+// terminar, el Rofl devuelto queda sin origen. En el resto de casos se usa ParseFile, que carga el
+// archivo completo para que los segmentos sigan disponibles.
+func parseBatchFile(path string, metadataOnly bool, opts []Option) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
+	if !metadataOnly {
+		return ParseFile(path, opts...)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error abriendo archivo: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error leyendo archivo: %w", err)
+	}
+	r, metaErr, statsErrs, err := NewFromReaderAt(file, info.Size(), opts...)
+	if r != nil {
+		r.SetSource(nil, 0)
+	}
+	return r, metaErr, statsErrs, err
+}
+
+// ParseDir busca recursivamente los archivos .rofl de dir y los parsea con ParseMany
+func ParseDir(ctx context.Context, dir string, workers int, opts ...Option) (<-chan Result, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".rofl" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error recorriendo %s: %w", dir, err)
+	}
+	return ParseMany(ctx, paths, workers, opts...), nil
+}
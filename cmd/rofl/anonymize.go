@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// runAnonymize anonimiza un replay y lo escribe como un nuevo .rofl o como JSON
+func runAnonymize(args []string) error {
+	fs := flag.NewFlagSet("anonymize", flag.ContinueOnError)
+	salt := fs.String("salt", os.Getenv("ROFL_SALT"), "salt secreto para derivar los seudónimos (por defecto $ROFL_SALT)")
+	out := fs.String("o", "", "archivo de salida (por defecto <archivo>.anon.rofl, o stdout con -json)")
+	asJSON := fs.Bool("json", false, "escribe la metadata anonimizada como JSON en lugar de un replay")
+	keepPayload := fs.Bool("keep-payload", false, "conserva los chunks y keyframes, que siguen conteniendo los datos personales de los jugadores")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("uso: rofl anonymize [-salt SALT] [-o salida] [-json] [-keep-payload] <archivo.rofl>")
+	}
+	if *salt == "" {
+		return errors.New("se necesita un salt: usa -salt o la variable ROFL_SALT")
+	}
+
+	path := fs.Arg(0)
+	r, _, _, err := roflparser.ParseFile(path)
+	if err != nil {
+		return err
+	}
+	// Encode no sabe escribir ROFL2: se avisa antes de anonimizar en lugar de fallar al final
+	if r.FormatVersion == model.FormatV2 && !*asJSON {
+		return fmt.Errorf("%s: los replays %s no se pueden escribir como .rofl; usa -json para obtener la metadata anonimizada", path, r.FormatVersion)
+	}
+	anonymize := roflparser.Anonymize
+	if *keepPayload {
+		fmt.Fprintln(os.Stderr, "aviso: -keep-payload conserva los chunks y keyframes, que se pueden descifrar y contienen los datos personales de los jugadores; el replay resultante no es anónimo")
+		anonymize = roflparser.AnonymizeMetadata
+	}
+	if err := anonymize(r, []byte(*salt)); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		jsonBytes, err := json.MarshalIndent(r.Metadata, "", "  ")
+		if err != nil {
+			return err
+		}
+		jsonBytes = append(jsonBytes, '\n')
+		if *out == "" {
+			_, err = os.Stdout.Write(jsonBytes)
+			return err
+		}
+		return os.WriteFile(*out, jsonBytes, 0644)
+	}
+
+	if *out == "" {
+		*out = strings.TrimSuffix(path, ".rofl") + ".anon.rofl"
+	}
+	return roflparser.WriteFile(*out, r)
+}
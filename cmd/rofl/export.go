@@ -0,0 +1,220 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/export"
+	"github.com/pointedsec/rofl-parser/model"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// exporters son los formatos de rofl export
+var exporters = map[string]func(args []string) error{
+	"csv":     runExportCSV,
+	"parquet": runExportParquet,
+	"sqlite":  runExportSQLite,
+}
+
+// runExport exporta las estadísticas de los jugadores de varios replays al formato indicado
+func runExport(args []string) error {
+	if len(args) == 0 {
+		return errors.New("uso: rofl export <csv|parquet|sqlite> [opciones] <archivo.rofl|directorio>...")
+	}
+	run, ok := exporters[args[0]]
+	if !ok {
+		return fmt.Errorf("formato desconocido %q", args[0])
+	}
+	return run(args[1:])
+}
+
+// runExportCSV escribe una fila por jugador y partida en CSV
+func runExportCSV(args []string) error {
+	fs := flag.NewFlagSet("export csv", flag.ContinueOnError)
+	out := fs.String("o", "", "archivo de salida (por defecto stdout)")
+	columns := fs.String("columns", "", "columnas separadas por comas (por defecto todas)")
+	workers := fs.Int("workers", 0, "número de archivos que se parsean en paralelo (por defecto uno por CPU)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("uso: rofl export csv [-o salida.csv] [-columns A,B] <archivo.rofl|directorio>...")
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	var opts []export.CSVOption
+	if *columns != "" {
+		opts = append(opts, export.WithColumns(strings.Split(*columns, ",")...))
+	}
+	csvw := export.NewCSVWriter(w, opts...)
+
+	paths, err := collectPaths(fs.Args())
+	if err != nil {
+		return err
+	}
+	parseErr := parseInOrder(paths, *workers, csvw.Add)
+	if err := csvw.Flush(); err != nil {
+		return err
+	}
+	return parseErr
+}
+
+// runExportSQLite carga los replays en una base de datos SQLite con las tablas games, teams y participants
+func runExportSQLite(args []string) error {
+	fs := flag.NewFlagSet("export sqlite", flag.ContinueOnError)
+	out := fs.String("o", "replays.db", "base de datos SQLite de destino; se crea si no existe")
+	workers := fs.Int("workers", 0, "número de archivos que se parsean en paralelo (por defecto uno por CPU)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("uso: rofl export sqlite [-o replays.db] <archivo.rofl|directorio>...")
+	}
+
+	db, err := sql.Open("sqlite3", *out)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	sqlw, err := export.NewSQLiteWriter(db)
+	if err != nil {
+		return err
+	}
+
+	paths, err := collectPaths(fs.Args())
+	if err != nil {
+		return err
+	}
+	return parseInOrder(paths, *workers, sqlw.Add)
+}
+
+// runExportParquet escribe un dataset Parquet con un archivo por lote de replays
+func runExportParquet(args []string) error {
+	fs := flag.NewFlagSet("export parquet", flag.ContinueOnError)
+	out := fs.String("o", "parquet", "directorio de destino del dataset")
+	batch := fs.Int("batch", 1000, "número de replays por archivo Parquet")
+	workers := fs.Int("workers", 0, "número de archivos que se parsean en paralelo (por defecto uno por CPU)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("uso: rofl export parquet [-o directorio] [-batch N] <archivo.rofl|directorio>...")
+	}
+
+	dataset, err := export.NewParquetDataset(*out, *batch)
+	if err != nil {
+		return err
+	}
+	paths, err := collectPaths(fs.Args())
+	if err != nil {
+		return err
+	}
+	parseErr := parseInOrder(paths, *workers, dataset.Add)
+	if err := dataset.Close(); err != nil {
+		return err
+	}
+	for _, file := range dataset.Files() {
+		fmt.Println(file)
+	}
+	return parseErr
+}
+
+// collectPaths expande los directorios de args a los archivos .rofl que contienen, sin repetir archivos
+func collectPaths(args []string) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(arg)
+			continue
+		}
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(path) == ".rofl" {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// parseInOrder parsea paths en paralelo y llama a fn con cada replay en el orden de paths, para que
+// la salida no dependa de qué archivo termina antes. Los archivos que fallan se informan por stderr
+// y se omiten.
+func parseInOrder(paths []string, workers int, fn func(r *model.Rofl) error) error {
+	failed := 0
+	resultsInOrder(paths, workers, func(res roflparser.Result) {
+		if res.Err == nil {
+			res.Err = fn(res.Rofl)
+		}
+		if res.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", res.Path, res.Err)
+			failed++
+		}
+	})
+	if failed > 0 {
+		return fmt.Errorf("%d de %d archivos no se pudieron exportar", failed, len(paths))
+	}
+	return nil
+}
+
+// resultsInOrder parsea paths en paralelo y llama a fn con cada resultado, incluidos los fallidos,
+// en el orden de paths. Solo se parsean a la vez los workers archivos siguientes al último entregado,
+// así que un archivo lento no obliga a retener en memoria todos los replays que terminan detrás de él.
+func resultsInOrder(paths []string, workers int, fn func(res roflparser.Result)) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	// Cada archivo tiene su propio canal; el búfer de pending limita los parseos en curso a los
+	// workers-1 encolados más el que espera fn
+	pending := make(chan chan roflparser.Result, workers-1)
+	go func() {
+		defer close(pending)
+		for i, path := range paths {
+			result := make(chan roflparser.Result, 1)
+			pending <- result
+			go func() {
+				r, metaErr, statsErrs, err := roflparser.ParseFile(path)
+				result <- roflparser.Result{Path: path, Rofl: r, MetadataErr: metaErr, StatsErrs: statsErrs, Err: err,
+					Done: i + 1, Total: len(paths)}
+			}()
+		}
+	}()
+	for result := range pending {
+		fn(<-result)
+	}
+}
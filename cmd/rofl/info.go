@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// runInfo muestra un resumen legible de cada replay
+func runInfo(args []string) error {
+	fs := flag.NewFlagSet("info", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("uso: rofl info <archivo.rofl>...")
+	}
+	for i, path := range fs.Args() {
+		if i > 0 {
+			fmt.Println()
+		}
+		r, _, _, err := roflparser.ParseFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if err := printInfo(os.Stdout, path, r); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// printInfo escribe la versión, la duración, el ganador y los jugadores de cada equipo
+func printInfo(w io.Writer, path string, r *model.Rofl) error {
+	// Los errores de conversión no impiden mostrar el resumen
+	game, err := roflparser.Summarize(r.Metadata)
+	if game.Players == nil && err != nil {
+		return err
+	}
+
+	version := game.GameVersion
+	if version == "" {
+		version = "desconocida"
+	} else if !r.Version.IsZero() {
+		version += " (parche " + r.Version.Patch() + ")"
+	}
+	fmt.Fprintf(w, "Archivo:   %s\n", path)
+	fmt.Fprintf(w, "Formato:   %s\n", r.FormatVersion)
+	fmt.Fprintf(w, "Versión:   %s\n", version)
+	fmt.Fprintf(w, "Duración:  %s\n", formatGameLength(game.GameLength))
+	fmt.Fprintf(w, "Modo:      %s (confianza %.0f%%)\n", game.Mode.Mode, game.Mode.Confidence*100)
+	if r.PayloadHeader.GameId != 0 {
+		fmt.Fprintf(w, "GameId:    %d\n", r.PayloadHeader.GameId)
+	}
+	fmt.Fprintf(w, "Segmentos: %d chunks, %d keyframes\n", len(r.Chunks), len(r.Keyframes))
+	fmt.Fprintf(w, "Jugadores: %d\n", len(game.Players))
+	winner := teamName(game.Winner)
+	if game.EndedInEarlySurrender {
+		winner += " (rendición temprana)"
+	} else if game.EndedInSurrender {
+		winner += " (rendición)"
+	}
+	fmt.Fprintf(w, "Ganador:   %s\n", winner)
+
+	for _, team := range []model.Team{model.TeamBlue, model.TeamRed, model.TeamUnknown} {
+		var members []model.PlayerStats
+		for _, p := range game.Players {
+			if p.Team == team {
+				members = append(members, p)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nEquipo %s", teamName(team))
+		if t := game.Team(team); t != nil {
+			result := "derrota"
+			if t.Win {
+				result = "victoria"
+			}
+			fmt.Fprintf(w, " - %s - %d/%d/%d, %d de oro, torres %d, inhibidores %d, dragones %d, barones %d, heraldos %d, larvas %d, Atakhan %d",
+				result, t.Kills, t.Deaths, t.Assists, t.GoldEarned, t.TurretsKilled, t.InhibitorsKilled,
+				t.DragonKills, t.BaronKills, t.RiftHeraldKills, t.HordeKills, t.AtakhanKills)
+		}
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  JUGADOR\tCAMPEÓN\tPOSICIÓN\tNIVEL\tK/D/A\tORO\tCS")
+		for _, p := range members {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%d\t%d/%d/%d\t%d\t%d\n", playerName(p), p.Skin, p.TeamPosition,
+				p.Level, p.ChampionsKilled, p.NumDeaths, p.Assists, p.GoldEarned, p.MinionsKilled+p.NeutralMinionsKilled)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatGameLength convierte la duración en milisegundos de gameLength a mm:ss
+func formatGameLength(ms int) string {
+	d := time.Duration(ms) * time.Millisecond
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// teamName devuelve el nombre legible de un equipo
+func teamName(t model.Team) string {
+	switch t {
+	case model.TeamBlue:
+		return "azul (100)"
+	case model.TeamRed:
+		return "rojo (200)"
+	default:
+		return "desconocido"
+	}
+}
+
+// playerName devuelve el Riot ID del jugador o, en parches antiguos, su nombre de invocador
+func playerName(p model.PlayerStats) string {
+	if p.RiotIDGameName == "" {
+		return p.Name
+	}
+	if p.RiotIDTagLine == "" {
+		return p.RiotIDGameName
+	}
+	return p.RiotIDGameName + "#" + p.RiotIDTagLine
+}
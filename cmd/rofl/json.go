@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/export"
+)
+
+// runJSON escribe en stdout la metadata del replay con las estadísticas decodificadas en stats o,
+// con -ndjson, una línea JSON por partida o por jugador de cada replay
+func runJSON(args []string) error {
+	fs := flag.NewFlagSet("json", flag.ContinueOnError)
+	compact := fs.Bool("compact", false, "escribe el JSON en una sola línea")
+	ndjson := fs.Bool("ndjson", false, "escribe una línea JSON por replay, con sus errores de validación; admite varios archivos y directorios")
+	perPlayer := fs.Bool("per-player", false, "con -ndjson, escribe una línea por jugador en lugar de por partida")
+	workers := fs.Int("workers", 0, "con -ndjson, número de archivos que se parsean en paralelo (por defecto uno por CPU)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *ndjson {
+		return runNDJSON(fs.Args(), *perPlayer, *workers)
+	}
+	if fs.NArg() != 1 {
+		return errors.New("uso: rofl json [-compact] <archivo.rofl> | rofl json -ndjson [-per-player] <archivo.rofl|directorio>...")
+	}
+
+	r, _, _, err := roflparser.ParseFile(fs.Arg(0), roflparser.WithMetadataOnly())
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	if !*compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(r.Metadata)
+}
+
+// runNDJSON escribe los replays de args en NDJSON. Los archivos que no se pueden parsear se escriben
+// como una línea con su error.
+func runNDJSON(args []string, perPlayer bool, workers int) error {
+	if len(args) == 0 {
+		return errors.New("uso: rofl json -ndjson [-per-player] <archivo.rofl|directorio>...")
+	}
+	paths, err := collectPaths(args)
+	if err != nil {
+		return err
+	}
+	mode := export.NDJSONPerGame
+	if perPlayer {
+		mode = export.NDJSONPerPlayer
+	}
+	enc := export.NewNDJSONEncoder(os.Stdout, mode)
+
+	failed := 0
+	var writeErr error
+	resultsInOrder(paths, workers, func(res roflparser.Result) {
+		if res.Err != nil {
+			failed++
+		}
+		if writeErr == nil {
+			writeErr = enc.Encode(res)
+		}
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d de %d archivos no se pudieron parsear", failed, len(paths))
+	}
+	return nil
+}
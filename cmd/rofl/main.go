@@ -0,0 +1,54 @@
+// rofl es la herramienta de línea de comandos para trabajar con archivos .rofl
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// command es un subcomando de la herramienta
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"anonymize": {"sustituye los identificadores de los jugadores por seudónimos", runAnonymize},
+	"export":    {"exporta las estadísticas de los jugadores de varios replays (csv, parquet, sqlite)", runExport},
+	"info":      {"muestra un resumen de la partida: versión, duración, equipos y ganador", runInfo},
+	"json":      {"escribe la metadata y las estadísticas decodificadas como JSON", runJSON},
+	"schema":    {"genera el esquema de claves esperadas de un parche a partir de replays de ejemplo", runSchema},
+	"validate":  {"muestra los campos faltantes o extra y falla si hay problemas", runValidate},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "rofl: subcomando desconocido %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "rofl %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// usage muestra la lista de subcomandos
+func usage() {
+	fmt.Fprintln(os.Stderr, "Uso: rofl <subcomando> [opciones] <archivo.rofl>")
+	fmt.Fprintln(os.Stderr, "\nSubcomandos:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/model"
+	"github.com/pointedsec/rofl-parser/schema"
+)
+
+// runSchema genera la entrada del registro de esquemas a partir de uno o varios replays de ejemplo
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	out := fs.String("o", "", "archivo de salida (por defecto stdout)")
+	versions := fs.String("versions", "", "intervalo de parches del esquema, como 15.1-15.4, o unversioned para los replays sin versión (por defecto los parches de los replays)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("uso: rofl schema [-versions 15.1-15.4] [-o esquema.json] <archivo.rofl|directorio>...")
+	}
+
+	paths, err := collectPaths(fs.Args())
+	if err != nil {
+		return err
+	}
+	replays := make([]*model.Rofl, 0, len(paths))
+	for _, path := range paths {
+		r, _, _, err := roflparser.ParseFile(path, roflparser.WithMetadataOnly())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		replays = append(replays, r)
+	}
+
+	var versionRange model.VersionRange
+	if *versions != "" {
+		versionRange, err = model.ParseVersionRange(*versions)
+	} else {
+		versionRange, err = samplesRange(replays)
+	}
+	if err != nil {
+		return err
+	}
+	s, err := schema.Generate(versionRange, replays...)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// samplesRange devuelve el intervalo que va del parche más antiguo al más reciente de los replays o,
+// si ninguno indica su versión, el intervalo de los replays sin versión
+func samplesRange(replays []*model.Rofl) (model.VersionRange, error) {
+	unversioned := 0
+	for _, r := range replays {
+		if r.Version.IsZero() {
+			unversioned++
+		}
+	}
+	switch {
+	case unversioned == len(replays):
+		return model.UnversionedRange(), nil
+	case unversioned > 0:
+		return model.VersionRange{}, errors.New("hay replays con y sin versión; genera un esquema para cada grupo o usa -versions")
+	}
+
+	var from, to model.GameVersion
+	for _, r := range replays {
+		if from.IsZero() || r.Version.ComparePatch(from) < 0 {
+			from = r.Version
+		}
+		if to.IsZero() || r.Version.ComparePatch(to) > 0 {
+			to = r.Version
+		}
+	}
+	return model.VersionRange{From: model.PatchRange(from).From, To: model.PatchRange(to).To}, nil
+}
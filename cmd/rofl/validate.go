@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/model"
+	"github.com/pointedsec/rofl-parser/schema"
+)
+
+// runValidate muestra los errores de validación de cada replay y falla si alguno tiene problemas
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "trata además como error una cabecera inconsistente, valores no convertibles, un número de jugadores anómalo o PUUID repetidos")
+	schemasDir := fs.String("schemas", "", "directorio con esquemas adicionales generados con rofl schema")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("uso: rofl validate [-strict] [-schemas directorio] <archivo.rofl>...")
+	}
+
+	var opts []roflparser.Option
+	if *strict {
+		opts = append(opts, roflparser.WithStrict(), roflparser.WithStrictValidation())
+	}
+	if *schemasDir != "" {
+		// Los esquemas del directorio se registran después de los incluidos, así que tienen prioridad
+		extra, err := schema.LoadDir(*schemasDir)
+		if err != nil {
+			return err
+		}
+		registry := schema.NewRegistry(schema.Default().Schemas()...)
+		for _, s := range extra {
+			registry.Register(s)
+		}
+		opts = append(opts, roflparser.WithSchemas(registry))
+	}
+	failed := 0
+	for _, path := range fs.Args() {
+		if !validateFile(path, opts) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d de %d archivos con problemas", failed, fs.NArg())
+	}
+	return nil
+}
+
+// validateFile parsea un replay, muestra sus problemas y devuelve si es válido
+func validateFile(path string, opts []roflparser.Option) bool {
+	_, metaErr, statsErrs, err := roflparser.ParseFile(path, opts...)
+	// Los campos faltantes y extra del *model.ValidationError se muestran agrupados más abajo
+	var validationErr *model.ValidationError
+	if err != nil && !errors.As(err, &validationErr) {
+		fmt.Printf("%s: error: %v\n", path, err)
+		return false
+	}
+
+	problems := 0
+	if metaErr != nil {
+		if len(metaErr.MissingFields) > 0 {
+			fmt.Printf("%s: Campos faltantes en Metadata: %v\n", path, metaErr.MissingFields)
+			problems++
+		}
+		if len(metaErr.ExtraFields) > 0 {
+			fmt.Printf("%s: Campos extra en Metadata: %v\n", path, metaErr.ExtraFields)
+			problems++
+		}
+	}
+	for _, statsErr := range statsErrs {
+		if len(statsErr.MissingFields) > 0 {
+			fmt.Printf("%s: Jugador %d - Campos faltantes en Stats: %v\n", path, statsErr.PlayerIndex, statsErr.MissingFields)
+			problems++
+		}
+		if len(statsErr.ExtraFields) > 0 {
+			fmt.Printf("%s: Jugador %d - Campos extra en Stats: %v\n", path, statsErr.PlayerIndex, statsErr.ExtraFields)
+			problems++
+		}
+	}
+	if validationErr != nil {
+		for _, p := range validationErr.Problems {
+			if p.Kind != model.ProblemMissingField && p.Kind != model.ProblemExtraField {
+				fmt.Printf("%s: %s\n", path, p)
+				problems++
+			}
+		}
+	}
+	if problems > 0 {
+		return false
+	}
+	fmt.Printf("%s: OK\n", path)
+	return true
+}
@@ -0,0 +1,61 @@
+package roflparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errores centinela devueltos por el parser. Siempre llegan envueltos en un *ParseError,
+// así que se comprueban con errors.Is y el detalle se obtiene con errors.As.
+var (
+	// ErrInvalidMagic indica que el archivo no empieza por el magic number RIOT
+	ErrInvalidMagic = errors.New("magic number inválido")
+	// ErrUnsupportedFormat indica que el magic es válido pero la versión del contenedor no está soportada
+	ErrUnsupportedFormat = errors.New("versión de contenedor no soportada")
+	// ErrTruncatedHeader indica que el archivo termina antes de completar la cabecera
+	ErrTruncatedHeader = errors.New("cabecera truncada")
+	// ErrInconsistentHeader indica que los offsets de la cabecera no son coherentes con el archivo (modo estricto)
+	ErrInconsistentHeader = errors.New("cabecera inconsistente")
+	// ErrMetadataNotFound indica que no se pudo localizar el bloque de metadata JSON
+	ErrMetadataNotFound = errors.New("no se encontró la metadata")
+	// ErrMetadataMalformed indica que el bloque de metadata no es JSON válido o no encaja en MetadataJson
+	ErrMetadataMalformed = errors.New("metadata mal formada")
+	// ErrStatsMalformed indica que statsJson no es un array JSON de objetos válido
+	ErrStatsMalformed = errors.New("statsJson mal formado")
+	// ErrPayloadMalformed indica que el payload header o el índice de segmentos son inválidos
+	ErrPayloadMalformed = errors.New("payload mal formado")
+)
+
+// Secciones del archivo usadas en ParseError.Section
+const (
+	SectionMagic         = "magic"
+	SectionSignature     = "signature"
+	SectionLengths       = "lengths"
+	SectionMetadata      = "metadata"
+	SectionStats         = "stats"
+	SectionPayloadHeader = "payloadHeader"
+	SectionSegments      = "segments"
+)
+
+// ParseError describe un fallo de parseo: la sección del archivo, el offset en bytes donde
+// se produjo y el error subyacente, que normalmente envuelve uno de los errores centinela
+type ParseError struct {
+	Section string
+	Offset  int64
+	Err     error
+}
+
+// Error implementa la interfaz error
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("error en %s (offset %d): %v", e.Section, e.Offset, e.Err)
+}
+
+// Unwrap permite usar errors.Is y errors.As con el error subyacente
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newParseError crea un *ParseError para la sección y el offset dados
+func newParseError(section string, offset int64, err error) error {
+	return &ParseError{Section: section, Offset: offset, Err: err}
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// CSVOption configura un CSVWriter
+type CSVOption func(*CSVWriter)
+
+// WithColumns limita la salida a las columnas dadas y en ese orden. Las columnas que no existan
+// en un replay se escriben vacías.
+func WithColumns(columns ...string) CSVOption {
+	return func(c *CSVWriter) {
+		c.columns = append([]string(nil), columns...)
+	}
+}
+
+// CSVWriter escribe una fila por jugador y partida. Sin WithColumns, las columnas son la unión
+// de las de todos los replays añadidos, así que las filas se guardan hasta Flush; con WithColumns
+// se escriben según se añaden.
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []string
+	rows    []map[string]string
+	seen    map[string]bool
+	header  bool
+}
+
+// NewCSVWriter crea un CSVWriter que escribe en w
+func NewCSVWriter(w io.Writer, opts ...CSVOption) *CSVWriter {
+	c := &CSVWriter{w: csv.NewWriter(w), seen: map[string]bool{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Add añade las filas de los jugadores de r
+func (c *CSVWriter) Add(r *model.Rofl) error {
+	rows, err := playerRows(r)
+	if err != nil {
+		return err
+	}
+	if c.columns == nil {
+		for _, row := range rows {
+			for key := range row {
+				c.seen[key] = true
+			}
+		}
+		c.rows = append(c.rows, rows...)
+		return nil
+	}
+	for _, row := range rows {
+		if err := c.writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush escribe las filas pendientes y vacía el buffer. Sin WithColumns debe llamarse una sola
+// vez, después de añadir todos los replays.
+func (c *CSVWriter) Flush() error {
+	if c.columns == nil {
+		c.columns = unionColumns(c.seen)
+	}
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	for _, row := range c.rows {
+		if err := c.writeRow(row); err != nil {
+			return err
+		}
+	}
+	c.rows = nil
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// writeHeader escribe la cabecera si aún no se ha escrito
+func (c *CSVWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	if err := c.w.Write(c.columns); err != nil {
+		return fmt.Errorf("error escribiendo cabecera CSV: %w", err)
+	}
+	return nil
+}
+
+// writeRow escribe los valores de row en el orden de las columnas
+func (c *CSVWriter) writeRow(row map[string]string) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	record := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		record[i] = row[col]
+	}
+	if err := c.w.Write(record); err != nil {
+		return fmt.Errorf("error escribiendo fila CSV: %w", err)
+	}
+	return nil
+}
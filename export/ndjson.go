@@ -0,0 +1,149 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// NDJSONMode indica qué representa cada línea de la salida NDJSON
+type NDJSONMode int
+
+const (
+	// NDJSONPerGame escribe una línea por partida con las estadísticas de todos los jugadores
+	NDJSONPerGame NDJSONMode = iota
+	// NDJSONPerPlayer escribe una línea por jugador y partida
+	NDJSONPerPlayer
+)
+
+// gameRecord es la línea NDJSON de una partida
+type gameRecord struct {
+	Path            string                             `json:"path,omitempty"`
+	GameID          uint64                             `json:"gameId,omitempty"`
+	Format          string                             `json:"format,omitempty"`
+	GameLength      int                                `json:"gameLength"`
+	GameVersion     string                             `json:"gameVersion"`
+	GameMode        model.GameModeInference            `json:"gameMode"`
+	LastGameChunkID int                                `json:"lastGameChunkId"`
+	LastKeyFrameID  int                                `json:"lastKeyFrameId"`
+	Stats           []map[string]interface{}           `json:"stats"`
+	Validation      *gameValidation                    `json:"validation,omitempty"`
+	players         []model.PlayerStatsValidationError // validación de cada jugador, para el modo por jugador
+}
+
+// gameValidation reúne los errores de validación de la metadata y de cada jugador
+type gameValidation struct {
+	Metadata *model.MetadataValidationError     `json:"metadata,omitempty"`
+	Players  []model.PlayerStatsValidationError `json:"players,omitempty"`
+}
+
+// playerRecord es la línea NDJSON de un jugador
+type playerRecord struct {
+	Path        string                            `json:"path,omitempty"`
+	GameID      uint64                            `json:"gameId,omitempty"`
+	GameLength  int                               `json:"gameLength"`
+	GameVersion string                            `json:"gameVersion"`
+	GameMode    model.GameMode                    `json:"gameMode"`
+	PlayerIndex int                               `json:"playerIndex"`
+	Stats       map[string]interface{}            `json:"stats"`
+	Validation  *model.PlayerStatsValidationError `json:"validation,omitempty"`
+}
+
+// errorRecord es la línea NDJSON de un archivo que no se pudo parsear
+type errorRecord struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// NDJSONEncoder escribe replays como JSON delimitado por saltos de línea: un registro compacto
+// por línea, listo para jq o para un recolector de logs
+type NDJSONEncoder struct {
+	enc  *json.Encoder
+	mode NDJSONMode
+}
+
+// NewNDJSONEncoder crea un NDJSONEncoder que escribe en w una línea por partida o por jugador según mode
+func NewNDJSONEncoder(w io.Writer, mode NDJSONMode) *NDJSONEncoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &NDJSONEncoder{enc: enc, mode: mode}
+}
+
+// Encode escribe el replay de res junto con sus errores de validación. Si res.Err no es nil se
+// escribe una única línea con la ruta y el error, de modo que los fallos quedan en la misma salida.
+func (e *NDJSONEncoder) Encode(res roflparser.Result) error {
+	if res.Err != nil {
+		return e.enc.Encode(errorRecord{Path: res.Path, Error: res.Err.Error()})
+	}
+	game := newGameRecord(res)
+	if e.mode == NDJSONPerGame {
+		return e.enc.Encode(game)
+	}
+	for idx, stats := range game.Stats {
+		player := playerRecord{
+			Path:        game.Path,
+			GameID:      game.GameID,
+			GameLength:  game.GameLength,
+			GameVersion: game.GameVersion,
+			GameMode:    game.GameMode.Mode,
+			PlayerIndex: idx,
+			Stats:       stats,
+		}
+		if idx < len(game.players) && hasProblems(game.players[idx].MissingFields, game.players[idx].ExtraFields) {
+			player.Validation = &game.players[idx]
+		}
+		if err := e.enc.Encode(player); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeRofl escribe r sin ruta ni errores de validación
+func (e *NDJSONEncoder) EncodeRofl(r *model.Rofl) error {
+	return e.Encode(roflparser.Result{Rofl: r})
+}
+
+// newGameRecord construye el registro de una partida. Las estadísticas se toman de Metadata.Stats
+// o, si no se rellenó, de StatsJSON.
+func newGameRecord(res roflparser.Result) gameRecord {
+	r := res.Rofl
+	stats := r.Metadata.Stats
+	if stats == nil && r.Metadata.StatsJSON != "" {
+		json.Unmarshal([]byte(r.Metadata.StatsJSON), &stats)
+	}
+	game := gameRecord{
+		Path:            res.Path,
+		GameID:          r.PayloadHeader.GameId,
+		Format:          r.FormatVersion.String(),
+		GameLength:      r.Metadata.GameLength,
+		GameVersion:     r.Metadata.GameVersion,
+		GameMode:        r.GameMode,
+		LastGameChunkID: r.Metadata.LastGameChunkID,
+		LastKeyFrameID:  r.Metadata.LastKeyFrameID,
+		Stats:           stats,
+		players:         res.StatsErrs,
+	}
+
+	// Solo se incluyen los errores de validación que indican algún problema
+	validation := gameValidation{}
+	if meta := res.MetadataErr; meta != nil && hasProblems(meta.MissingFields, meta.ExtraFields) {
+		validation.Metadata = meta
+	}
+	for _, statsErr := range res.StatsErrs {
+		if hasProblems(statsErr.MissingFields, statsErr.ExtraFields) {
+			validation.Players = append(validation.Players, statsErr)
+		}
+	}
+	if validation.Metadata != nil || len(validation.Players) > 0 {
+		game.Validation = &validation
+	}
+	return game
+}
+
+// hasProblems indica si un error de validación tiene campos faltantes o extra
+func hasProblems(missing, extra []string) bool {
+	return len(missing) > 0 || len(extra) > 0
+}
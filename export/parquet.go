@@ -0,0 +1,284 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/writer"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// ColumnExtra es la columna Parquet con las claves de statsJson que no tienen campo en
+// model.PlayerStats, serializadas como un objeto JSON
+const ColumnExtra = "extra"
+
+// parquetColumn es una columna del esquema Parquet: la clave de statsJson, su tipo Parquet
+// y el índice del campo de model.PlayerStats del que se lee (-1 para las columnas de la partida)
+type parquetColumn struct {
+	name  string
+	tag   string
+	field int
+}
+
+var (
+	durationType   = reflect.TypeOf(time.Duration(0))
+	parquetColumns = collectParquetColumns()
+	parquetSchema  = buildParquetSchema(parquetColumns)
+)
+
+// collectParquetColumns deriva el esquema de los campos de model.PlayerStats: enteros y enums
+// numéricos como INT64, booleanos como BOOLEAN, duraciones como INT64 en segundos y el resto
+// como strings UTF8. Todas las columnas son opcionales porque no todos los parches tienen todas las claves.
+func collectParquetColumns() []parquetColumn {
+	const (
+		int64Tag  = "type=INT64, repetitiontype=OPTIONAL"
+		stringTag = "type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"
+		boolTag   = "type=BOOLEAN, repetitiontype=OPTIONAL"
+	)
+	columns := []parquetColumn{
+		{ColumnGameID, int64Tag, -1},
+		{ColumnGameVersion, stringTag, -1},
+		{ColumnGameLength, int64Tag, -1},
+		{ColumnGameMode, stringTag, -1},
+		{ColumnPlayerIndex, int64Tag, -1},
+	}
+	t := reflect.TypeOf(model.PlayerStats{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		tag := stringTag
+		switch f.Type.Kind() {
+		case reflect.Bool:
+			tag = boolTag
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			tag = int64Tag
+		}
+		columns = append(columns, parquetColumn{name, tag, i})
+	}
+	return append(columns, parquetColumn{ColumnExtra, stringTag, -1})
+}
+
+// buildParquetSchema genera el esquema en el formato JSON de parquet-go
+func buildParquetSchema(columns []parquetColumn) string {
+	type field struct {
+		Tag string
+	}
+	schema := struct {
+		Tag    string
+		Fields []field
+	}{Tag: "name=parquet_go_root, repetitiontype=REQUIRED"}
+	for _, col := range columns {
+		schema.Fields = append(schema.Fields, field{Tag: "name=" + col.name + ", " + col.tag})
+	}
+	b, _ := json.Marshal(schema)
+	return string(b)
+}
+
+// ParquetColumns devuelve las columnas de los archivos Parquet en el orden del esquema
+func ParquetColumns() []string {
+	names := make([]string, len(parquetColumns))
+	for i, col := range parquetColumns {
+		names[i] = col.name
+	}
+	return names
+}
+
+// ParquetWriter escribe una fila por jugador y partida en un archivo Parquet con un esquema tipado
+// derivado de model.PlayerStats. Las claves que un replay no tiene se escriben como null.
+type ParquetWriter struct {
+	pw *writer.JSONWriter
+}
+
+// NewParquetWriter crea un ParquetWriter que escribe en w. Hay que llamar a Close para escribir
+// el pie del archivo.
+func NewParquetWriter(w io.Writer) (*ParquetWriter, error) {
+	pw, err := writer.NewJSONWriter(parquetSchema, writerfile.NewWriterFile(w), 4)
+	if err != nil {
+		return nil, fmt.Errorf("error creando el escritor Parquet: %w", err)
+	}
+	return &ParquetWriter{pw: pw}, nil
+}
+
+// Add añade las filas de los jugadores de r
+func (p *ParquetWriter) Add(r *model.Rofl) error {
+	if r.Metadata.StatsJSON == "" {
+		return nil
+	}
+	// Los valores que no se pueden convertir quedan a cero, igual que en el resto de exportadores
+	players, err := roflparser.ParsePlayerStats(r.Metadata.StatsJSON)
+	if players == nil {
+		return err
+	}
+	for idx, player := range players {
+		row, err := parquetRow(r, idx, player)
+		if err != nil {
+			return fmt.Errorf("jugador %d: %w", idx, err)
+		}
+		if err := p.pw.Write(row); err != nil {
+			return fmt.Errorf("error escribiendo fila Parquet: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close escribe los datos pendientes y el pie del archivo. No cierra el io.Writer subyacente.
+func (p *ParquetWriter) Close() error {
+	if err := p.pw.WriteStop(); err != nil {
+		return fmt.Errorf("error cerrando el archivo Parquet: %w", err)
+	}
+	return nil
+}
+
+// parquetRow construye la fila JSON de un jugador. Las claves ausentes en statsJson quedan a null.
+func parquetRow(r *model.Rofl, idx int, player model.PlayerStats) (string, error) {
+	present := player.Raw()
+	v := reflect.ValueOf(player)
+	row := make(map[string]any, len(parquetColumns))
+	if r.PayloadHeader.GameId != 0 {
+		row[ColumnGameID] = r.PayloadHeader.GameId
+	}
+	row[ColumnGameVersion] = r.Metadata.GameVersion
+	row[ColumnGameLength] = r.Metadata.GameLength
+	row[ColumnGameMode] = r.GameMode.Mode.String()
+	row[ColumnPlayerIndex] = idx
+	for _, col := range parquetColumns {
+		if col.field < 0 {
+			continue
+		}
+		if _, ok := present[col.name]; !ok {
+			continue
+		}
+		field := v.Field(col.field)
+		switch {
+		case field.Type() == durationType:
+			row[col.name] = int64(field.Interface().(time.Duration).Seconds())
+		case field.Kind() == reflect.String:
+			row[col.name] = field.String()
+		default:
+			row[col.name] = field.Interface()
+		}
+	}
+	if len(player.Extra) > 0 {
+		extra, err := json.Marshal(player.Extra)
+		if err != nil {
+			return "", err
+		}
+		row[ColumnExtra] = string(extra)
+	}
+	b, err := json.Marshal(row)
+	return string(b), err
+}
+
+// ParquetDataset reparte las filas de los replays añadidos en archivos Parquet de como máximo
+// batchSize replays cada uno, llamados part-00000.parquet, part-00001.parquet... dentro de dir
+type ParquetDataset struct {
+	dir       string
+	batchSize int
+	file      *os.File
+	writer    *ParquetWriter
+	inBatch   int
+	files     []string
+}
+
+// NewParquetDataset crea el directorio dir si no existe. Si batchSize <= 0 se usan lotes de 1000 replays.
+func NewParquetDataset(dir string, batchSize int) (*ParquetDataset, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &ParquetDataset{dir: dir, batchSize: batchSize}, nil
+}
+
+// Add añade los jugadores de r al lote actual, abriendo un archivo nuevo cuando el anterior está completo
+func (d *ParquetDataset) Add(r *model.Rofl) error {
+	if d.writer != nil && d.inBatch >= d.batchSize {
+		if err := d.closeBatch(); err != nil {
+			return err
+		}
+	}
+	if d.writer == nil {
+		path := filepath.Join(d.dir, fmt.Sprintf("part-%05d.parquet", len(d.files)))
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		w, err := NewParquetWriter(file)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		d.file, d.writer = file, w
+		d.files = append(d.files, path)
+	}
+	d.inBatch++
+	return d.writer.Add(r)
+}
+
+// Close cierra el último archivo del dataset
+func (d *ParquetDataset) Close() error {
+	if d.writer == nil {
+		return nil
+	}
+	return d.closeBatch()
+}
+
+// Files devuelve las rutas de los archivos escritos
+func (d *ParquetDataset) Files() []string {
+	return append([]string(nil), d.files...)
+}
+
+// closeBatch cierra el archivo del lote actual
+func (d *ParquetDataset) closeBatch() error {
+	err := d.writer.Close()
+	if closeErr := d.file.Close(); err == nil {
+		err = closeErr
+	}
+	d.file, d.writer, d.inBatch = nil, nil, 0
+	return err
+}
+
+// WriteParquetDir parsea en paralelo los .rofl de dir con roflparser.ParseDir y los escribe como
+// un dataset Parquet en outDir, con un archivo por cada batchSize replays. Los archivos que no se
+// pueden parsear se omiten y se devuelven agrupados en el error, junto con los archivos escritos.
+func WriteParquetDir(ctx context.Context, dir, outDir string, batchSize, workers int) ([]string, error) {
+	results, err := roflparser.ParseDir(ctx, dir, workers)
+	if err != nil {
+		return nil, err
+	}
+	dataset, err := NewParquetDataset(outDir, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for res := range results {
+		if res.Err == nil {
+			res.Err = dataset.Add(res.Rofl)
+		}
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Path, res.Err))
+		}
+	}
+	if err := dataset.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return dataset.Files(), errors.Join(errs...)
+}
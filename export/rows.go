@@ -0,0 +1,109 @@
+// Package export escribe las estadísticas de los jugadores de uno o varios replays en formatos
+// tabulares, con una fila por jugador y partida.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// Columnas de cada fila con los datos de la partida, que van antes de las estadísticas del jugador
+const (
+	ColumnGameID      = "gameId"
+	ColumnGameVersion = "gameVersion"
+	ColumnGameLength  = "gameLength"
+	ColumnGameMode    = "gameMode"
+	ColumnPlayerIndex = "playerIndex"
+)
+
+// gameColumns son las columnas de la partida en el orden en que se escriben
+var gameColumns = []string{ColumnGameID, ColumnGameVersion, ColumnGameLength, ColumnGameMode, ColumnPlayerIndex}
+
+// statsColumns son las claves de statsJson en el orden de los campos de model.PlayerStatsJson
+var statsColumns = jsonKeys(reflect.TypeOf(model.PlayerStatsJson{}))
+
+// GameColumns devuelve las columnas con los datos de la partida
+func GameColumns() []string {
+	return append([]string(nil), gameColumns...)
+}
+
+// StatsColumns devuelve las columnas de estadísticas conocidas, en el orden de model.PlayerStatsJson
+func StatsColumns() []string {
+	return append([]string(nil), statsColumns...)
+}
+
+// jsonKeys devuelve los nombres de los campos JSON de una estructura
+func jsonKeys(t reflect.Type) []string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+// playerRows devuelve una fila por jugador de r con las columnas de la partida y todas las claves de statsJson
+func playerRows(r *model.Rofl) ([]map[string]string, error) {
+	if r.Metadata.StatsJSON == "" {
+		return nil, nil
+	}
+	var players []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(r.Metadata.StatsJSON), &players); err != nil {
+		return nil, fmt.Errorf("statsJson mal formado: %w", err)
+	}
+
+	gameId := ""
+	if r.PayloadHeader.GameId != 0 {
+		gameId = strconv.FormatUint(r.PayloadHeader.GameId, 10)
+	}
+	rows := make([]map[string]string, len(players))
+	for idx, player := range players {
+		row := make(map[string]string, len(player)+len(gameColumns))
+		for key, raw := range player {
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				// Los valores de statsJson son strings; cualquier otro se copia tal cual
+				value = string(raw)
+			}
+			row[key] = value
+		}
+		row[ColumnGameID] = gameId
+		row[ColumnGameVersion] = r.Metadata.GameVersion
+		row[ColumnGameLength] = strconv.Itoa(r.Metadata.GameLength)
+		row[ColumnGameMode] = r.GameMode.Mode.String()
+		row[ColumnPlayerIndex] = strconv.Itoa(idx)
+		rows[idx] = row
+	}
+	return rows, nil
+}
+
+// unionColumns devuelve las columnas presentes en seen en el orden documentado: primero las de la
+// partida, después las estadísticas conocidas y por último las desconocidas en orden alfabético
+func unionColumns(seen map[string]bool) []string {
+	columns := make([]string, 0, len(seen))
+	known := make(map[string]bool, len(gameColumns)+len(statsColumns))
+	for _, group := range [][]string{gameColumns, statsColumns} {
+		for _, col := range group {
+			known[col] = true
+			if seen[col] {
+				columns = append(columns, col)
+			}
+		}
+	}
+	var unknown []string
+	for col := range seen {
+		if !known[col] {
+			unknown = append(unknown, col)
+		}
+	}
+	sort.Strings(unknown)
+	return append(columns, unknown...)
+}
@@ -0,0 +1,228 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	roflparser "github.com/pointedsec/rofl-parser"
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// ErrNoGameID indica que el replay no tiene GameId ni estadísticas con las que identificar la partida
+var ErrNoGameID = errors.New("el replay no tiene GameId ni statsJson con los que identificar la partida")
+
+// sqliteSchema crea las tablas games, teams y participants y los índices únicos de participants
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS games (
+	game_id            INTEGER PRIMARY KEY,
+	game_version       TEXT NOT NULL,
+	game_length_ms     INTEGER NOT NULL,
+	last_game_chunk_id INTEGER NOT NULL,
+	last_keyframe_id   INTEGER NOT NULL,
+	winning_team       INTEGER,
+	game_mode          TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS teams (
+	game_id        INTEGER NOT NULL REFERENCES games(game_id),
+	team_id        INTEGER NOT NULL,
+	win            INTEGER NOT NULL,
+	kills          INTEGER NOT NULL,
+	deaths         INTEGER NOT NULL,
+	assists        INTEGER NOT NULL,
+	gold_earned    INTEGER NOT NULL,
+	turrets_killed INTEGER NOT NULL,
+	dragon_kills   INTEGER NOT NULL,
+	baron_kills    INTEGER NOT NULL,
+	PRIMARY KEY (game_id, team_id)
+);
+CREATE TABLE IF NOT EXISTS participants (
+	game_id             INTEGER NOT NULL REFERENCES games(game_id),
+	player_index        INTEGER NOT NULL,
+	puuid               TEXT NOT NULL,
+	summoner_id         TEXT NOT NULL,
+	name                TEXT NOT NULL,
+	riot_id_game_name   TEXT NOT NULL,
+	riot_id_tag_line    TEXT NOT NULL,
+	team_id             INTEGER NOT NULL,
+	champion            TEXT NOT NULL,
+	position            TEXT NOT NULL,
+	win                 INTEGER NOT NULL,
+	level               INTEGER NOT NULL,
+	kills               INTEGER NOT NULL,
+	deaths              INTEGER NOT NULL,
+	assists             INTEGER NOT NULL,
+	gold_earned         INTEGER NOT NULL,
+	creep_score         INTEGER NOT NULL,
+	damage_to_champions INTEGER NOT NULL,
+	vision_score        INTEGER NOT NULL,
+	time_played_s       INTEGER NOT NULL,
+	stats_json          TEXT NOT NULL
+);
+-- Cada participante se identifica por partida y PUUID y, en los parches sin PUUID, por partida e
+-- índice de jugador. Así, volver a importar una partida con los jugadores en otro orden actualiza sus filas.
+CREATE UNIQUE INDEX IF NOT EXISTS participants_puuid ON participants (game_id, puuid) WHERE puuid != '';
+CREATE UNIQUE INDEX IF NOT EXISTS participants_index ON participants (game_id, player_index) WHERE puuid = '';
+`
+
+const upsertGame = `
+INSERT INTO games (game_id, game_version, game_length_ms, last_game_chunk_id, last_keyframe_id, winning_team, game_mode)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (game_id) DO UPDATE SET
+	game_version = excluded.game_version,
+	game_length_ms = excluded.game_length_ms,
+	last_game_chunk_id = excluded.last_game_chunk_id,
+	last_keyframe_id = excluded.last_keyframe_id,
+	winning_team = excluded.winning_team,
+	game_mode = excluded.game_mode`
+
+const upsertTeam = `
+INSERT INTO teams (game_id, team_id, win, kills, deaths, assists, gold_earned, turrets_killed, dragon_kills, baron_kills)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (game_id, team_id) DO UPDATE SET
+	win = excluded.win,
+	kills = excluded.kills,
+	deaths = excluded.deaths,
+	assists = excluded.assists,
+	gold_earned = excluded.gold_earned,
+	turrets_killed = excluded.turrets_killed,
+	dragon_kills = excluded.dragon_kills,
+	baron_kills = excluded.baron_kills`
+
+const insertParticipant = `
+INSERT INTO participants (game_id, player_index, puuid, summoner_id, name, riot_id_game_name, riot_id_tag_line,
+	team_id, champion, position, win, level, kills, deaths, assists, gold_earned, creep_score,
+	damage_to_champions, vision_score, time_played_s, stats_json)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+const updateParticipant = ` DO UPDATE SET
+	player_index = excluded.player_index,
+	puuid = excluded.puuid,
+	summoner_id = excluded.summoner_id,
+	name = excluded.name,
+	riot_id_game_name = excluded.riot_id_game_name,
+	riot_id_tag_line = excluded.riot_id_tag_line,
+	team_id = excluded.team_id,
+	champion = excluded.champion,
+	position = excluded.position,
+	win = excluded.win,
+	level = excluded.level,
+	kills = excluded.kills,
+	deaths = excluded.deaths,
+	assists = excluded.assists,
+	gold_earned = excluded.gold_earned,
+	creep_score = excluded.creep_score,
+	damage_to_champions = excluded.damage_to_champions,
+	vision_score = excluded.vision_score,
+	time_played_s = excluded.time_played_s,
+	stats_json = excluded.stats_json`
+
+// upsertParticipantByPUUID y upsertParticipantByIndex usan como clave el índice único que corresponde
+// a cada participante: el de PUUID si lo tiene y el de índice de jugador si no
+const (
+	upsertParticipantByPUUID = insertParticipant + `
+ON CONFLICT (game_id, puuid) WHERE puuid != ''` + updateParticipant
+	upsertParticipantByIndex = insertParticipant + `
+ON CONFLICT (game_id, player_index) WHERE puuid = ''` + updateParticipant
+)
+
+// SQLiteWriter carga replays en una base de datos SQLite con las tablas games, teams y participants.
+// Cada replay se escribe con upserts en una transacción, así que volver a importar el mismo replay
+// actualiza sus filas en lugar de duplicarlas. No depende de ningún driver concreto: db puede abrirse
+// con github.com/mattn/go-sqlite3 o con cualquier otro driver de SQLite 3.24 o superior.
+type SQLiteWriter struct {
+	db *sql.DB
+}
+
+// NewSQLiteWriter crea las tablas en db si no existen
+func NewSQLiteWriter(db *sql.DB) (*SQLiteWriter, error) {
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, fmt.Errorf("error creando el esquema SQLite: %w", err)
+	}
+	return &SQLiteWriter{db: db}, nil
+}
+
+// gameKey devuelve la clave de la partida en games.game_id. Es PayloadHeader.GameId si el replay lo
+// tiene; si no (ROFL2, WithMetadataOnly o replays anonimizados) se deriva de la versión, la duración
+// y statsJson, de modo que volver a importar el replay da la misma clave. Las claves derivadas son
+// negativas para no chocar con los GameId de Riot.
+func gameKey(r *model.Rofl) (int64, error) {
+	// Los GameId caben en un int64, el tipo entero de SQLite
+	if r.PayloadHeader.GameId != 0 {
+		return int64(r.PayloadHeader.GameId), nil
+	}
+	if r.Metadata.StatsJSON == "" {
+		return 0, ErrNoGameID
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%d\x00%s", r.Metadata.GameVersion, r.Metadata.GameLength, r.Metadata.StatsJSON)
+	return -int64(binary.BigEndian.Uint64(h.Sum(nil))>>1) - 1, nil
+}
+
+// Add inserta o actualiza la partida, los equipos y los participantes de r
+func (s *SQLiteWriter) Add(r *model.Rofl) error {
+	gameId, err := gameKey(r)
+	if err != nil {
+		return err
+	}
+	// Los valores que no se pueden convertir quedan a cero en las columnas tipadas; stats_json guarda
+	// el objeto original de cada jugador tal y como aparece en statsJson
+	game, err := roflparser.Summarize(r.Metadata)
+	if game.Players == nil && err != nil {
+		return err
+	}
+	var rawPlayers []json.RawMessage
+	if r.Metadata.StatsJSON != "" {
+		if err := json.Unmarshal([]byte(r.Metadata.StatsJSON), &rawPlayers); err != nil {
+			return fmt.Errorf("%w: %v", roflparser.ErrStatsMalformed, err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	winner := sql.NullInt64{}
+	if game.Winner != model.TeamUnknown {
+		winner = sql.NullInt64{Int64: int64(game.Winner), Valid: true}
+	}
+	_, err = tx.Exec(upsertGame, gameId, r.Metadata.GameVersion, r.Metadata.GameLength,
+		r.Metadata.LastGameChunkID, r.Metadata.LastKeyFrameID, winner, game.Mode.Mode.String())
+	if err != nil {
+		return fmt.Errorf("error guardando la partida: %w", err)
+	}
+	for _, t := range []model.TeamSummary{game.Blue, game.Red} {
+		if t.Players == 0 {
+			continue
+		}
+		_, err := tx.Exec(upsertTeam, gameId, int(t.Team), t.Win, t.Kills, t.Deaths, t.Assists, t.GoldEarned,
+			t.TurretsKilled, t.DragonKills, t.BaronKills)
+		if err != nil {
+			return fmt.Errorf("error guardando el equipo %d: %w", int(t.Team), err)
+		}
+	}
+	for idx, p := range game.Players {
+		var statsJson bytes.Buffer
+		if err := json.Compact(&statsJson, rawPlayers[idx]); err != nil {
+			return fmt.Errorf("jugador %d: %w", idx, err)
+		}
+		upsert := upsertParticipantByPUUID
+		if p.PUUID == "" {
+			upsert = upsertParticipantByIndex
+		}
+		_, err := tx.Exec(upsert, gameId, idx, p.PUUID, p.SummonerID, p.Name, p.RiotIDGameName, p.RiotIDTagLine,
+			int(p.Team), p.Skin, string(p.TeamPosition), p.Win, p.Level, p.ChampionsKilled, p.NumDeaths, p.Assists,
+			p.GoldEarned, p.MinionsKilled+p.NeutralMinionsKilled, p.TotalDamageDealtToChampions, p.VisionScore,
+			int64(p.TimePlayed.Seconds()), statsJson.String())
+		if err != nil {
+			return fmt.Errorf("error guardando el jugador %d: %w", idx, err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// testPlayers son las estadísticas de dos jugadores, una por línea de statsJson
+var testPlayers = []string{
+	`{"PUUID":"puuid-a","NAME":"a","TEAM":"100","WIN":"Win","GOLD_EARNED":"abc"}`,
+	`{"PUUID":"puuid-b","NAME":"b","TEAM":"200","WIN":"Fail","GOLD_EARNED":"900"}`,
+}
+
+// openTestWriter crea una base de datos SQLite vacía en un directorio temporal
+func openTestWriter(t *testing.T) (*sql.DB, *SQLiteWriter) {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "replays.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	w, err := NewSQLiteWriter(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, w
+}
+
+// testReplay devuelve un replay v1 con los jugadores dados
+func testReplay(gameId uint64, players ...string) *model.Rofl {
+	statsJson := "["
+	for i, p := range players {
+		if i > 0 {
+			statsJson += ","
+		}
+		statsJson += p
+	}
+	statsJson += "]"
+	return &model.Rofl{
+		FormatVersion: model.FormatV1,
+		PayloadHeader: model.PayloadHeader{GameId: gameId},
+		Metadata:      model.MetadataJson{GameVersion: "15.4.1", GameLength: 1800000, StatsJSON: statsJson},
+	}
+}
+
+func TestSQLiteStoresOriginalStatsJSON(t *testing.T) {
+	db, w := openTestWriter(t)
+	if err := w.Add(testReplay(1, testPlayers...)); err != nil {
+		t.Fatal(err)
+	}
+	var gold int
+	var statsJson string
+	err := db.QueryRow(`SELECT gold_earned, stats_json FROM participants WHERE puuid = 'puuid-a'`).Scan(&gold, &statsJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gold != 0 || statsJson != testPlayers[0] {
+		t.Fatalf("gold_earned = %d, stats_json = %s; se esperaba 0 y %s", gold, statsJson, testPlayers[0])
+	}
+}
+
+func TestSQLiteReimportReorderedPlayers(t *testing.T) {
+	db, w := openTestWriter(t)
+	if err := w.Add(testReplay(1, testPlayers[0], testPlayers[1])); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(testReplay(1, testPlayers[1], testPlayers[0])); err != nil {
+		t.Fatalf("reimportar con otro orden: %v", err)
+	}
+	var n, index int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM participants`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`SELECT player_index FROM participants WHERE puuid = 'puuid-a'`).Scan(&index); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || index != 1 {
+		t.Fatalf("%d participantes, puuid-a en el índice %d; se esperaban 2 y 1", n, index)
+	}
+}
+
+func TestSQLiteGameKeyWithoutGameId(t *testing.T) {
+	db, w := openTestWriter(t)
+	r := testReplay(0, testPlayers...)
+	r.FormatVersion = model.FormatV2
+	if err := w.Add(r); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(r); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	var gameId int64
+	if err := db.QueryRow(`SELECT COUNT(*), MIN(game_id) FROM games`).Scan(&n, &gameId); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || gameId >= 0 {
+		t.Fatalf("%d partidas con game_id %d; se esperaba una con clave negativa", n, gameId)
+	}
+
+	if err := w.Add(&model.Rofl{FormatVersion: model.FormatV2}); !errors.Is(err, ErrNoGameID) {
+		t.Fatalf("sin GameId ni statsJson: %v, se esperaba ErrNoGameID", err)
+	}
+}
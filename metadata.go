@@ -0,0 +1,69 @@
+package roflparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// magicSize es el tamaño del magic number al inicio del archivo
+const magicSize = 6
+
+// headerSize es el tamaño fijo de la cabecera: magic (6) + signature (256) + lengths (26)
+const headerSize = 288
+
+// extractMetadata localiza el bloque de metadata JSON. Primero intenta usar los offsets de
+// la cabecera y, solo si son inconsistentes con el archivo, recurre a buscar el bloque por contenido.
+// Devuelve también el offset donde empieza el bloque.
+func extractMetadata(src *source, lengths model.Lengths) ([]byte, model.MetadataStrategy, int64, error) {
+	if metaBytes, ok := metadataFromHeader(src, lengths); ok {
+		return metaBytes, model.MetadataFromHeader, int64(lengths.MetadataOffset), nil
+	}
+	metaBytes, start, err := metadataFromScan(src)
+	if err != nil {
+		return nil, model.MetadataFromScan, 0, err
+	}
+	return metaBytes, model.MetadataFromScan, start, nil
+}
+
+// metadataFromHeader lee exactamente Lengths.Metadata bytes desde Lengths.MetadataOffset,
+// comprobando que el rango esté dentro del archivo y que contenga un objeto JSON válido
+func metadataFromHeader(src *source, lengths model.Lengths) ([]byte, bool) {
+	start := int64(lengths.MetadataOffset)
+	length := int64(lengths.Metadata)
+	if length == 0 || start < headerSize || !src.inRange(start, length) {
+		return nil, false
+	}
+	metaBytes, err := src.section(start, length)
+	if err != nil || metaBytes[0] != '{' || !json.Valid(metaBytes) {
+		return nil, false
+	}
+	return metaBytes, true
+}
+
+// metadataFromScan busca el bloque que empieza por {"gameLength": y delega en el decodificador
+// JSON la detección del final, de modo que las llaves dentro de strings no afectan al resultado.
+// Devuelve también el offset donde empieza el bloque.
+func metadataFromScan(src *source) ([]byte, int64, error) {
+	start, err := src.index([]byte(`{"gameLength":`), 0, src.size)
+	if err != nil {
+		return nil, 0, newParseError(SectionMetadata, 0, err)
+	}
+	if start == -1 {
+		return nil, 0, newParseError(SectionMetadata, 0,
+			fmt.Errorf("%w: no se encontró el inicio del bloque JSON con '\"gameLength\":'", ErrMetadataNotFound))
+	}
+	dec := json.NewDecoder(io.NewSectionReader(src.r, start, src.size-start))
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return nil, 0, newParseError(SectionMetadata, start,
+			fmt.Errorf("%w: no se encontró el final del bloque JSON: %v", ErrMetadataMalformed, err))
+	}
+	metaBytes, err := src.section(start, dec.InputOffset())
+	if err != nil {
+		return nil, 0, newParseError(SectionMetadata, start, err)
+	}
+	return metaBytes, start, nil
+}
@@ -0,0 +1,318 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotString indica que un valor de statsJson no es un string JSON, como un número o un booleano
+var ErrNotString = errors.New("el valor no es un string")
+
+// StatFieldError describe un valor de statsJson que no se pudo convertir a su tipo en PlayerStats
+type StatFieldError struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Err   error  `json:"-"`
+}
+
+// Error implementa la interfaz error
+func (e StatFieldError) Error() string {
+	return fmt.Sprintf("%s=%q: %v", e.Key, e.Value, e.Err)
+}
+
+// Unwrap devuelve el error de conversión subyacente
+func (e StatFieldError) Unwrap() error {
+	return e.Err
+}
+
+// StatsConversionError reúne los campos de un jugador que no se pudieron convertir
+type StatsConversionError struct {
+	PlayerIndex int              `json:"playerIndex"`
+	Fields      []StatFieldError `json:"fields"`
+}
+
+// Error implementa la interfaz error
+func (e *StatsConversionError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Error()
+	}
+	return fmt.Sprintf("jugador %d: %d campos no convertibles: %s", e.PlayerIndex, len(e.Fields), strings.Join(parts, "; "))
+}
+
+// statField asocia una clave de statsJson con el índice del campo en PlayerStats
+type statField struct {
+	key   string
+	index int
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	teamType     = reflect.TypeOf(TeamUnknown)
+	positionType = reflect.TypeOf(PositionNone)
+
+	playerStatsFields = collectStatFields(reflect.TypeOf(PlayerStats{}))
+	playerStatsKeys   = statFieldKeys(playerStatsFields)
+)
+
+// boolFormats indica los valores con los que se escriben los indicadores que no usan "1"/"0"
+var boolFormats = map[string][2]string{
+	"WIN": {"Win", "Fail"},
+}
+
+// collectStatFields recorre las etiquetas json de PlayerStats
+func collectStatFields(t reflect.Type) []statField {
+	var fields []statField
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if key != "" && key != "-" {
+			fields = append(fields, statField{key: key, index: i})
+		}
+	}
+	return fields
+}
+
+// statFieldKeys devuelve el conjunto de claves con campo propio en PlayerStats
+func statFieldKeys(fields []statField) map[string]bool {
+	keys := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		keys[f.key] = true
+	}
+	return keys
+}
+
+// NewPlayerStats convierte las estadísticas crudas de un jugador (todas en string) en PlayerStats.
+// Las claves ausentes quedan con su valor cero y las que no tienen campo propio se guardan en Extra.
+// Los valores que no se pueden convertir también quedan a cero y se devuelven todos juntos en un
+// *StatsConversionError, sin descartar el resto.
+func NewPlayerStats(raw map[string]string) (PlayerStats, error) {
+	return newPlayerStats(raw, nil)
+}
+
+// DecodePlayerStats convierte el objeto JSON de un jugador de statsJson en PlayerStats, como
+// NewPlayerStats. Los valores que no son strings JSON (por ejemplo, un número) no impiden leer el
+// resto: su campo queda a cero, se informan como StatFieldError con ErrNotString y se conservan
+// tal cual para volver a serializarlos. Solo devuelve un error distinto de *StatsConversionError
+// si data no es un objeto JSON.
+func DecodePlayerStats(data []byte) (PlayerStats, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return PlayerStats{}, err
+	}
+	raw := make(map[string]string, len(fields))
+	var jsonValues map[string]string
+	for key, value := range fields {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			raw[key] = s
+			continue
+		}
+		var compact bytes.Buffer
+		json.Compact(&compact, value)
+		if jsonValues == nil {
+			jsonValues = make(map[string]string)
+		}
+		jsonValues[key] = compact.String()
+		raw[key] = compact.String()
+	}
+	return newPlayerStats(raw, jsonValues)
+}
+
+// newPlayerStats convierte raw en PlayerStats. jsonValues son las claves de raw cuyo valor original
+// no era un string JSON, con su texto JSON: no se convierten y se informan como ErrNotString.
+func newPlayerStats(raw, jsonValues map[string]string) (PlayerStats, error) {
+	var stats PlayerStats
+	v := reflect.ValueOf(&stats).Elem()
+	if len(jsonValues) > 0 {
+		stats.jsonValues = jsonValues
+	}
+
+	for key, value := range raw {
+		if !playerStatsKeys[key] {
+			if stats.Extra == nil {
+				stats.Extra = make(map[string]string)
+			}
+			stats.Extra[key] = value
+		}
+	}
+
+	var fieldErrs []StatFieldError
+	for _, f := range playerStatsFields {
+		value, ok := raw[f.key]
+		if !ok {
+			if stats.absent == nil {
+				stats.absent = make(map[string]bool)
+			}
+			stats.absent[f.key] = true
+			continue
+		}
+		if _, notString := jsonValues[f.key]; notString {
+			fieldErrs = append(fieldErrs, StatFieldError{Key: f.key, Value: value, Err: ErrNotString})
+		} else if err := setStatField(v.Field(f.index), value); err != nil {
+			fieldErrs = append(fieldErrs, StatFieldError{Key: f.key, Value: value, Err: err})
+		}
+		if formatted := formatStatField(f.key, v.Field(f.index)); formatted != value {
+			if stats.original == nil {
+				stats.original = make(map[string]originalStat)
+			}
+			stats.original[f.key] = originalStat{value: value, formatted: formatted}
+		}
+	}
+	var extraNotStrings []string
+	for key := range stats.Extra {
+		if _, notString := jsonValues[key]; notString {
+			extraNotStrings = append(extraNotStrings, key)
+		}
+	}
+	sort.Strings(extraNotStrings)
+	for _, key := range extraNotStrings {
+		fieldErrs = append(fieldErrs, StatFieldError{Key: key, Value: stats.Extra[key], Err: ErrNotString})
+	}
+	if len(fieldErrs) > 0 {
+		return stats, &StatsConversionError{Fields: fieldErrs}
+	}
+	return stats, nil
+}
+
+// Raw devuelve las estadísticas en el formato de statsJson: todos los valores como string,
+// incluidas las claves guardadas en Extra. Las claves que no venían en el statsJson original
+// se omiten y los valores que la conversión normalizó o no pudo convertir se escriben como
+// venían mientras no se modifique su campo, de modo que leer y volver a escribir no cambia nada.
+func (p PlayerStats) Raw() map[string]string {
+	raw := make(map[string]string, len(playerStatsFields)+len(p.Extra))
+	for key, value := range p.Extra {
+		raw[key] = value
+	}
+	v := reflect.ValueOf(p)
+	for _, f := range playerStatsFields {
+		if p.absent[f.key] {
+			continue
+		}
+		formatted := formatStatField(f.key, v.Field(f.index))
+		if orig, ok := p.original[f.key]; ok && orig.formatted == formatted {
+			formatted = orig.value
+		}
+		raw[f.key] = formatted
+	}
+	return raw
+}
+
+// MarshalJSON serializa las estadísticas en el formato de statsJson. Los valores que no eran strings
+// JSON se escriben con su tipo original mientras no se modifiquen.
+func (p PlayerStats) MarshalJSON() ([]byte, error) {
+	raw := p.Raw()
+	if len(p.jsonValues) == 0 {
+		return json.Marshal(raw)
+	}
+	out := make(map[string]json.RawMessage, len(raw))
+	for key, value := range raw {
+		if original, ok := p.jsonValues[key]; ok && original == value {
+			out[key] = json.RawMessage(value)
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = b
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON lee un objeto de statsJson con DecodePlayerStats. Las claves desconocidas se guardan
+// en Extra y, si algún valor no se puede convertir, se devuelve un *StatsConversionError tras rellenar el resto.
+func (p *PlayerStats) UnmarshalJSON(data []byte) error {
+	stats, err := DecodePlayerStats(data)
+	*p = stats
+	return err
+}
+
+// setStatField convierte value al tipo del campo y lo asigna
+func setStatField(field reflect.Value, value string) error {
+	switch field.Type() {
+	case durationType:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(time.Duration(n) * time.Second))
+		return nil
+	case teamType:
+		team, err := ParseTeam(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(team))
+		return nil
+	case positionType:
+		position, err := ParsePosition(value)
+		if err != nil {
+			return err
+		}
+		field.SetString(string(position))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Bool:
+		b, err := parseStatBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.String:
+		field.SetString(value)
+	default:
+		return fmt.Errorf("tipo de campo no soportado: %s", field.Type())
+	}
+	return nil
+}
+
+// formatStatField escribe el valor de un campo tal y como aparece en statsJson
+func formatStatField(key string, field reflect.Value) string {
+	if field.Type() == durationType {
+		return strconv.FormatInt(int64(time.Duration(field.Int())/time.Second), 10)
+	}
+
+	// Team se escribe como número y Position como string, igual que sus tipos base
+	switch field.Kind() {
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10)
+	case reflect.Bool:
+		format, ok := boolFormats[key]
+		if !ok {
+			format = [2]string{"1", "0"}
+		}
+		if field.Bool() {
+			return format[0]
+		}
+		return format[1]
+	default:
+		return field.String()
+	}
+}
+
+// parseStatBool interpreta los indicadores de statsJson: "1"/"0", "true"/"false" y, para WIN, "Win"/"Fail"
+func parseStatBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "1", "true", "win":
+		return true, nil
+	case "0", "false", "fail", "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("valor booleano no válido")
+	}
+}
@@ -0,0 +1,78 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPlayerStatsRoundTrip(t *testing.T) {
+	raw := map[string]string{
+		"TEAM_POSITION":       "Invalid",
+		"INDIVIDUAL_POSITION": "",
+		"GOLD_EARNED":         "abc",
+		"CHAMPIONS_KILLED":    "007",
+		"WIN":                 "Win",
+		"TEAM":                "100",
+		"Missions_PorosFed":   "12",
+	}
+	stats, err := NewPlayerStats(raw)
+	var convErr *StatsConversionError
+	if !errors.As(err, &convErr) || len(convErr.Fields) != 1 || convErr.Fields[0].Key != "GOLD_EARNED" {
+		t.Fatalf("error de conversión inesperado: %v", err)
+	}
+	if got := stats.Raw(); !reflect.DeepEqual(got, raw) {
+		t.Fatalf("Raw() = %v, se esperaba %v", got, raw)
+	}
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back map[string]string
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, raw) {
+		t.Fatalf("json.Marshal = %s, se esperaba %v", b, raw)
+	}
+}
+
+func TestPlayerStatsRawModifiedField(t *testing.T) {
+	stats, _ := NewPlayerStats(map[string]string{"GOLD_EARNED": "abc", "TEAM_POSITION": "Invalid"})
+	stats.GoldEarned = 1500
+	stats.TeamPosition = PositionTop
+	raw := stats.Raw()
+	if raw["GOLD_EARNED"] != "1500" || raw["TEAM_POSITION"] != "TOP" {
+		t.Fatalf("los campos modificados deben escribirse con su valor nuevo: %v", raw)
+	}
+}
+
+func TestDecodePlayerStatsNonString(t *testing.T) {
+	data := []byte(`{"TEAM":"100","GOLD_EARNED":1500,"Missions_PorosFed":true}`)
+	stats, err := DecodePlayerStats(data)
+	var convErr *StatsConversionError
+	if !errors.As(err, &convErr) || len(convErr.Fields) != 2 {
+		t.Fatalf("error de conversión inesperado: %v", err)
+	}
+	for i, key := range []string{"GOLD_EARNED", "Missions_PorosFed"} {
+		if f := convErr.Fields[i]; f.Key != key || !errors.Is(f.Err, ErrNotString) {
+			t.Fatalf("Fields[%d] = %+v, se esperaba %s con ErrNotString", i, f, key)
+		}
+	}
+	if stats.Team != TeamBlue || stats.GoldEarned != 0 {
+		t.Fatalf("el resto de campos debe convertirse y el valor no string quedar a cero: %+v", stats)
+	}
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back, want map[string]any
+	json.Unmarshal(b, &back)
+	json.Unmarshal(data, &want)
+	if !reflect.DeepEqual(back, want) {
+		t.Fatalf("json.Marshal = %s, se esperaba %s", b, data)
+	}
+}
@@ -1,7 +1,15 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+)
+
 // MetadataValidationError representa los errores de validación del bloque Metadata JSON
 type MetadataValidationError struct {
+	// Schema es el intervalo de parches del esquema con el que se validó, o vacío si se usaron
+	// MetadataJson y PlayerStatsJson porque no había ninguno para la versión del replay
+	Schema        string   `json:"schema,omitempty"`
 	MissingFields []string `json:"missingFields,omitempty"`
 	ExtraFields   []string `json:"extraFields,omitempty"`
 }
@@ -12,3 +20,105 @@ type PlayerStatsValidationError struct {
 	MissingFields []string `json:"missingFields,omitempty"`
 	ExtraFields   []string `json:"extraFields,omitempty"`
 }
+
+// ValidationProblemKind clasifica los problemas que detecta la validación estricta
+type ValidationProblemKind int
+
+const (
+	// ProblemMissingField es una clave obligatoria del esquema que falta en la metadata o en un jugador
+	ProblemMissingField ValidationProblemKind = iota
+	// ProblemExtraField es una clave que el esquema no conoce
+	ProblemExtraField
+	// ProblemInvalidValue es un valor de statsJson que no se puede convertir a su tipo, como un
+	// texto en una estadística numérica
+	ProblemInvalidValue
+	// ProblemPlayerCount indica que el número de jugadores o su reparto en equipos no corresponde a ninguna cola
+	ProblemPlayerCount
+	// ProblemDuplicatePUUID indica que dos jugadores tienen el mismo PUUID
+	ProblemDuplicatePUUID
+)
+
+// String devuelve el nombre legible del tipo de problema
+func (k ValidationProblemKind) String() string {
+	switch k {
+	case ProblemMissingField:
+		return "missingField"
+	case ProblemExtraField:
+		return "extraField"
+	case ProblemInvalidValue:
+		return "invalidValue"
+	case ProblemPlayerCount:
+		return "playerCount"
+	case ProblemDuplicatePUUID:
+		return "duplicatePuuid"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText codifica el tipo de problema con su nombre legible
+func (k ValidationProblemKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// ValidationProblem es uno de los problemas de un ValidationError
+type ValidationProblem struct {
+	Kind ValidationProblemKind `json:"kind"`
+	// PlayerIndex es el jugador afectado, o -1 si el problema es de la metadata o de la partida
+	PlayerIndex int    `json:"playerIndex"`
+	Key         string `json:"key,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+// String describe el problema en una línea
+func (p ValidationProblem) String() string {
+	where := "partida"
+	if p.PlayerIndex >= 0 {
+		where = fmt.Sprintf("jugador %d", p.PlayerIndex)
+	}
+	var what string
+	switch p.Kind {
+	case ProblemMissingField:
+		what = "falta " + p.Key
+	case ProblemExtraField:
+		what = "clave inesperada " + p.Key
+	case ProblemInvalidValue:
+		what = fmt.Sprintf("valor inválido en %s: %s", p.Key, p.Detail)
+	default:
+		what = p.Detail
+	}
+	return where + ": " + what
+}
+
+// ValidationError es el error de la validación estricta: reúne todos los problemas encontrados
+// en el replay, que por lo demás se pudo parsear
+type ValidationError struct {
+	// Schema es el intervalo de parches del esquema usado, como en MetadataValidationError
+	Schema   string              `json:"schema,omitempty"`
+	Problems []ValidationProblem `json:"problems"`
+}
+
+// Error implementa la interfaz error con el número de problemas de cada tipo
+func (e *ValidationError) Error() string {
+	counts := map[ValidationProblemKind]int{}
+	for _, p := range e.Problems {
+		counts[p.Kind]++
+	}
+	var parts []string
+	for kind := ProblemMissingField; kind <= ProblemDuplicatePUUID; kind++ {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return fmt.Sprintf("validación estricta: %d problemas (%s)", len(e.Problems), strings.Join(parts, ", "))
+}
+
+// Has indica si hay algún problema del tipo dado
+func (e *ValidationError) Has(kind ValidationProblemKind) bool {
+	for _, p := range e.Problems {
+		if p.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package model
+
+// FormatVersion identifica la variante del contenedor .rofl según el magic number
+type FormatVersion uint8
+
+const (
+	// FormatUnknown es el valor cero, usado cuando el formato no se ha detectado
+	FormatUnknown FormatVersion = 0
+	// FormatV1 es el contenedor clásico (RIOT\x00\x00): cabecera con offsets y segmentos Blowfish + gzip
+	FormatV1 FormatVersion = 1
+	// FormatV2 es el contenedor ROFL2 (RIOT\x02\x00): segmentos zstd y metadata al final del archivo
+	FormatV2 FormatVersion = 2
+)
+
+// String devuelve el nombre legible del formato
+func (v FormatVersion) String() string {
+	switch v {
+	case FormatV1:
+		return "ROFL"
+	case FormatV2:
+		return "ROFL2"
+	default:
+		return "unknown"
+	}
+}
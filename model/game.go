@@ -0,0 +1,102 @@
+package model
+
+// TeamSummary reúne los totales de los jugadores de un equipo
+type TeamSummary struct {
+	Team    Team `json:"team"`
+	Win     bool `json:"win"`
+	Players int  `json:"players"`
+
+	Kills             int `json:"kills"`
+	Deaths            int `json:"deaths"`
+	Assists           int `json:"assists"`
+	GoldEarned        int `json:"goldEarned"`
+	DamageToChampions int `json:"damageToChampions"`
+
+	DragonKills      int `json:"dragonKills"`
+	BaronKills       int `json:"baronKills"`
+	RiftHeraldKills  int `json:"riftHeraldKills"`
+	AtakhanKills     int `json:"atakhanKills"`
+	HordeKills       int `json:"hordeKills"`
+	TurretsKilled    int `json:"turretsKilled"`
+	InhibitorsKilled int `json:"inhibitorsKilled"`
+
+	// Surrendered indica que el equipo perdió por rendición; EarlySurrendered, que se rindió antes de tiempo
+	Surrendered      bool `json:"surrendered"`
+	EarlySurrendered bool `json:"earlySurrendered"`
+}
+
+// add suma las estadísticas de un jugador al equipo
+func (t *TeamSummary) add(p PlayerStats) {
+	t.Players++
+	t.Win = t.Win || p.Win
+	t.Kills += p.ChampionsKilled
+	t.Deaths += p.NumDeaths
+	t.Assists += p.Assists
+	t.GoldEarned += p.GoldEarned
+	t.DamageToChampions += p.TotalDamageDealtToChampions
+	t.DragonKills += p.DragonKills
+	t.BaronKills += p.BaronKills
+	t.RiftHeraldKills += p.RiftHeraldKills
+	t.AtakhanKills += p.AtakhanKills
+	t.HordeKills += p.HordeKills
+	t.TurretsKilled += p.TurretsKilled
+	t.InhibitorsKilled += p.BarracksKilled
+	t.EarlySurrendered = t.EarlySurrendered || p.TeamEarlySurrendered
+}
+
+// Game es el resumen de una partida: los totales de cada equipo y el ganador
+type Game struct {
+	// GameLength es la duración de la partida en milisegundos, como en MetadataJson
+	GameLength  int               `json:"gameLength"`
+	GameVersion string            `json:"gameVersion"`
+	Mode        GameModeInference `json:"mode"`
+	Blue        TeamSummary       `json:"blue"`
+	Red         TeamSummary       `json:"red"`
+	// Winner es el equipo cuyos jugadores tienen WIN, o TeamUnknown si ninguno lo tiene (por ejemplo, en un remake)
+	Winner                Team          `json:"winner"`
+	EndedInSurrender      bool          `json:"endedInSurrender"`
+	EndedInEarlySurrender bool          `json:"endedInEarlySurrender"`
+	Players               []PlayerStats `json:"players"`
+}
+
+// NewGame resume la partida a partir de la metadata y las estadísticas tipadas de sus jugadores.
+// Los jugadores sin equipo azul o rojo (por ejemplo, en Arena) no se suman a ningún equipo.
+func NewGame(meta MetadataJson, players []PlayerStats) Game {
+	g := Game{
+		GameLength:  meta.GameLength,
+		GameVersion: meta.GameVersion,
+		Mode:        InferGameMode(players),
+		Blue:        TeamSummary{Team: TeamBlue},
+		Red:         TeamSummary{Team: TeamRed},
+		Players:     players,
+	}
+	for _, p := range players {
+		if t := g.Team(p.Team); t != nil {
+			t.add(p)
+		}
+		g.EndedInSurrender = g.EndedInSurrender || p.GameEndedInSurrender
+		g.EndedInEarlySurrender = g.EndedInEarlySurrender || p.GameEndedInEarlySurrender
+	}
+	switch {
+	case g.Blue.Win && !g.Red.Win:
+		g.Winner = TeamBlue
+	case g.Red.Win && !g.Blue.Win:
+		g.Winner = TeamRed
+	}
+	for _, t := range []*TeamSummary{&g.Blue, &g.Red} {
+		t.Surrendered = g.EndedInSurrender && g.Winner != TeamUnknown && !t.Win
+	}
+	return g
+}
+
+// Team devuelve el resumen del equipo dado, o nil si no es el azul ni el rojo
+func (g *Game) Team(team Team) *TeamSummary {
+	switch team {
+	case TeamBlue:
+		return &g.Blue
+	case TeamRed:
+		return &g.Red
+	default:
+		return nil
+	}
+}
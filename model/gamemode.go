@@ -0,0 +1,207 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// GameMode es el modo de juego deducido de las estadísticas, porque la metadata no incluye la cola
+type GameMode int
+
+const (
+	// GameModeUnknown indica que no hay pruebas suficientes para elegir un modo
+	GameModeUnknown GameMode = iota
+	// GameModeClassic es la Grieta del Invocador: normales, clasificatorias y Clash
+	GameModeClassic
+	// GameModeARAM es el Abismo de los Lamentos
+	GameModeARAM
+	// GameModeArena es Arena, con jugadores en parejas (PLAYER_SUBTEAM) y aumentos
+	GameModeArena
+	// GameModeURF agrupa los modos de la Grieta con enfriamientos reducidos (URF, ARURF)
+	GameModeURF
+	// GameModeSwarm es Enjambre, cooperativo contra la máquina con hasta cuatro jugadores en el mismo equipo
+	GameModeSwarm
+	// GameModeCustom es una partida con un reparto de jugadores que ninguna cola genera, como un 1 contra 1
+	GameModeCustom
+)
+
+// String devuelve el nombre legible del modo
+func (m GameMode) String() string {
+	switch m {
+	case GameModeClassic:
+		return "classic"
+	case GameModeARAM:
+		return "aram"
+	case GameModeArena:
+		return "arena"
+	case GameModeURF:
+		return "urf"
+	case GameModeSwarm:
+		return "swarm"
+	case GameModeCustom:
+		return "custom"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText codifica el modo con su nombre legible
+func (m GameMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// GameModeInference es el modo deducido, la confianza entre 0 y 1 y las pruebas en las que se basa
+type GameModeInference struct {
+	Mode       GameMode `json:"mode"`
+	Confidence float64  `json:"confidence"`
+	Evidence   []string `json:"evidence,omitempty"`
+}
+
+// urfCastsPerMinute es la mediana de habilidades lanzadas por minuto a partir de la cual una
+// partida de la Grieta se considera URF. En partidas normales ronda las 5-10.
+const urfCastsPerMinute = 20
+
+// InferGameMode deduce el modo de juego a partir de las claves y valores de las estadísticas, el
+// reparto en equipos y el número de jugadores. Las claves de misiones y eventos aparecen en todos
+// los modos con valor cero, así que solo cuentan como prueba cuando son mayores que cero.
+func InferGameMode(players []PlayerStats) GameModeInference {
+	if len(players) == 0 {
+		return GameModeInference{Evidence: []string{"la partida no tiene jugadores"}}
+	}
+
+	// Arena: parejas con PLAYER_SUBTEAM y aumentos
+	var subteam, augments int
+	for _, p := range players {
+		if p.PlayerSubteam > 0 {
+			subteam++
+		}
+		if p.PlayerAugment1 > 0 || p.PlayerAugment2 > 0 || p.PlayerAugment3 > 0 ||
+			p.PlayerAugment4 > 0 || p.PlayerAugment5 > 0 || p.PlayerAugment6 > 0 {
+			augments++
+		}
+	}
+	if subteam > 0 || augments > 0 {
+		inf := GameModeInference{Mode: GameModeArena, Confidence: 0.75}
+		if subteam > 0 {
+			inf.Evidence = append(inf.Evidence, fmt.Sprintf("PLAYER_SUBTEAM en %d de %d jugadores", subteam, len(players)))
+		}
+		if augments > 0 {
+			inf.Evidence = append(inf.Evidence, fmt.Sprintf("PLAYER_AUGMENT_* en %d de %d jugadores", augments, len(players)))
+		}
+		if subteam > 0 && augments > 0 {
+			inf.Confidence = 0.95
+		}
+		return inf
+	}
+
+	teams := map[Team]int{}
+	for _, p := range players {
+		teams[p.Team]++
+	}
+	layout := fmt.Sprintf("%d jugadores: %d en azul y %d en rojo", len(players), teams[TeamBlue], teams[TeamRed])
+	if teams[TeamBlue] == len(players) && len(players) <= 4 {
+		return GameModeInference{Mode: GameModeSwarm, Confidence: 0.6, Evidence: []string{layout + ", sin rivales"}}
+	}
+
+	// Grieta o Abismo: cada prueba suma puntos a uno de los dos mapas
+	var rift, aram int
+	var evidence []string
+	score := func(points *int, n int, format string, args ...any) {
+		*points += n
+		evidence = append(evidence, fmt.Sprintf(format, args...))
+	}
+	if n := sumExtra(players, "Missions_PorosFed"); n > 0 {
+		score(&aram, 3, "Missions_PorosFed = %d", n)
+	}
+	if n := sumExtra(players, "Missions_HexgatesUsed") + sumExtra(players, "Event_ARAM_Hexgates"); n > 0 {
+		score(&aram, 3, "portales hextech usados %d veces", n)
+	}
+	var epic, neutral, positioned, withPosition int
+	for _, p := range players {
+		epic += p.DragonKills + p.BaronKills + p.RiftHeraldKills + p.HordeKills + p.AtakhanKills
+		neutral += p.NeutralMinionsKilled
+		// En los parches sin TEAM_POSITION su ausencia no indica nada
+		if !p.absent["TEAM_POSITION"] {
+			withPosition++
+		}
+		if p.TeamPosition != PositionNone {
+			positioned++
+		}
+	}
+	if epic > 0 {
+		score(&rift, 3, "%d monstruos épicos de la Grieta (dragones, barones, heraldos, larvas, Atakhan)", epic)
+	}
+	if neutral > 0 {
+		score(&rift, 2, "%d monstruos neutrales de la jungla", neutral)
+	} else {
+		score(&aram, 1, "ningún monstruo neutral")
+	}
+	if positioned == len(players) {
+		score(&rift, 1, "TEAM_POSITION asignado a todos los jugadores")
+	} else if positioned == 0 && withPosition == len(players) {
+		score(&aram, 1, "ningún jugador con TEAM_POSITION")
+	}
+
+	inf := GameModeInference{Evidence: evidence}
+	switch {
+	case rift > aram:
+		inf.Mode = GameModeClassic
+		inf.Confidence = confidence(rift, aram)
+	case aram > rift:
+		inf.Mode = GameModeARAM
+		inf.Confidence = confidence(aram, rift)
+	default:
+		return inf
+	}
+
+	if inf.Mode == GameModeClassic {
+		if casts := medianCastsPerMinute(players); casts >= urfCastsPerMinute {
+			inf.Mode = GameModeURF
+			inf.Evidence = append(inf.Evidence, fmt.Sprintf("mediana de %.1f habilidades por minuto", casts))
+		}
+	}
+	if teams[TeamUnknown] > 0 || teams[TeamBlue] != teams[TeamRed] || len(players) != 10 {
+		inf.Mode = GameModeCustom
+		inf.Evidence = append(inf.Evidence, layout)
+	}
+	return inf
+}
+
+// confidence convierte los puntos del mapa elegido y del descartado en una confianza entre 0 y 1:
+// crece con las pruebas a favor y baja con las pruebas en contra
+func confidence(winner, loser int) float64 {
+	c := float64(winner) / float64(winner+loser+1)
+	return float64(int(c*100+0.5)) / 100
+}
+
+// sumExtra suma el valor numérico de una clave sin campo propio en PlayerStats en todos los jugadores
+func sumExtra(players []PlayerStats, key string) int {
+	total := 0
+	for _, p := range players {
+		if n, err := strconv.Atoi(p.Extra[key]); err == nil {
+			total += n
+		}
+	}
+	return total
+}
+
+// medianCastsPerMinute devuelve la mediana de habilidades (Q, W, E y R) lanzadas por minuto jugado
+func medianCastsPerMinute(players []PlayerStats) float64 {
+	var rates []float64
+	for _, p := range players {
+		if p.TimePlayed <= 0 {
+			continue
+		}
+		casts := p.Spell1Cast + p.Spell2Cast + p.Spell3Cast + p.Spell4Cast
+		rates = append(rates, float64(casts)/p.TimePlayed.Minutes())
+	}
+	if len(rates) == 0 {
+		return 0
+	}
+	sort.Float64s(rates)
+	if n := len(rates); n%2 == 0 {
+		return (rates[n/2-1] + rates[n/2]) / 2
+	}
+	return rates[len(rates)/2]
+}
@@ -1,7 +1,12 @@
 package model
 
+import (
+	"encoding/json"
+	"io"
+)
+
 type Lengths struct {
-	Header              uint32
+	Header              uint16
 	File                uint32
 	MetadataOffset      uint32
 	Metadata            uint32
@@ -11,24 +16,46 @@ type Lengths struct {
 }
 
 type Rofl struct {
-	Magic         [6]byte
+	Magic [6]byte
+	// FormatVersion indica la variante del contenedor detectada a partir de Magic
+	FormatVersion FormatVersion
 	Signature     [256]byte
 	Lengths       Lengths
 	Metadata      MetadataJson
-	PayloadHeader struct {
-		GameId              uint64
-		GameLength          uint32
-		KeyframeCount       uint32
-		ChunkCount          uint32
-		EndStartupChunkId   uint32
-		StartGameChunkId    uint32
-		KeyframeInterval    uint32
-		EncryptionKeyLength uint16
-		EncryptionKey       string
-	}
-	headerEnd int64
-	Chunks    []Chunk
-	Keyframes []Keyframe
+	// RawMetadata es el bloque JSON de metadata tal y como aparece en el archivo, con las claves que
+	// no tienen campo en MetadataJson. Encode lo usa para no perderlas al escribir.
+	RawMetadata json.RawMessage
+	// Version es Metadata.GameVersion ya parseada; queda a cero si gameVersion está vacío o no se puede leer
+	Version GameVersion
+	// GameMode es el modo de juego deducido de las estadísticas de los jugadores
+	GameMode GameModeInference
+	// MetadataStrategy indica cómo se localizó el bloque de metadata dentro del archivo
+	MetadataStrategy MetadataStrategy
+	PayloadHeader    PayloadHeader
+	// PayloadSkipped indica que el parser no leyó el payload (con WithMetadataOnly o porque la
+	// cabecera era inconsistente): PayloadHeader, Chunks y Keyframes están vacíos aunque el archivo
+	// los tenga. Encode no escribe un Rofl así salvo que se ponga a false para aceptar un replay
+	// sin payload.
+	PayloadSkipped bool
+	headerEnd      int64
+	Chunks         []Chunk
+	Keyframes      []Keyframe
+	// source y size permiten leer bajo demanda los datos de los segmentos
+	source io.ReaderAt
+	size   int64
+}
+
+type PayloadHeader struct {
+	GameId              uint64
+	GameLength          uint32
+	KeyframeCount       uint32
+	ChunkCount          uint32
+	EndStartupChunkId   uint32
+	StartGameChunkId    uint32
+	KeyframeInterval    uint32
+	EncryptionKeyLength uint16
+	// EncryptionKey es la clave de los segmentos codificada en base64, tal y como aparece en el archivo
+	EncryptionKey string
 }
 
 type MetadataJson struct {
@@ -285,13 +312,20 @@ type PlayerStatsJson struct {
 	Missions_ChampionTakedownsWhileGhosted      string `json:"Missions_ChampionTakedownsWhileGhosted"`
 }
 
+// Tipos de segmento tal y como aparecen en el índice del payload
+const (
+	SegmentTypeChunk    byte = 1
+	SegmentTypeKeyframe byte = 2
+)
+
 type Chunk struct {
 	Id        uint32
 	ChunkType byte
 	Length    uint32
 	NextId    uint32
-	Offset    uint32
-	Data      []byte
+	// Offset es la posición absoluta de los datos del chunk dentro del archivo
+	Offset uint32
+	Data   []byte
 }
 
 type Keyframe struct {
@@ -299,6 +333,7 @@ type Keyframe struct {
 	KeyframeType byte
 	Length       uint32
 	NextId       uint32
-	Offset       uint32
-	Data         []byte
+	// Offset es la posición absoluta de los datos del keyframe dentro del archivo
+	Offset uint32
+	Data   []byte
 }
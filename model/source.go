@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNoSource indica que los datos de un segmento no están cargados y no hay origen del que leerlos
+var ErrNoSource = errors.New("datos del segmento no cargados y sin origen de lectura")
+
+// SetSource asocia el origen del que se leen bajo demanda los datos de los segmentos
+func (r *Rofl) SetSource(src io.ReaderAt, size int64) {
+	r.source = src
+	r.size = size
+}
+
+// Source devuelve el origen del archivo y su tamaño, o nil si no se asoció ninguno
+func (r *Rofl) Source() (io.ReaderAt, int64) {
+	return r.source, r.size
+}
+
+// ChunkData devuelve los datos crudos de un chunk, leyéndolos del origen si no están cargados
+func (r *Rofl) ChunkData(c Chunk) ([]byte, error) {
+	if c.Data != nil {
+		return c.Data, nil
+	}
+	return r.readSegment(c.Offset, c.Length)
+}
+
+// KeyframeData devuelve los datos crudos de un keyframe, leyéndolos del origen si no están cargados
+func (r *Rofl) KeyframeData(k Keyframe) ([]byte, error) {
+	if k.Data != nil {
+		return k.Data, nil
+	}
+	return r.readSegment(k.Offset, k.Length)
+}
+
+// LoadData carga en memoria los datos de todos los chunks y keyframes que aún no lo estén
+func (r *Rofl) LoadData() error {
+	for i := range r.Chunks {
+		data, err := r.ChunkData(r.Chunks[i])
+		if err != nil {
+			return fmt.Errorf("chunk %d: %w", r.Chunks[i].Id, err)
+		}
+		r.Chunks[i].Data = data
+	}
+	for i := range r.Keyframes {
+		data, err := r.KeyframeData(r.Keyframes[i])
+		if err != nil {
+			return fmt.Errorf("keyframe %d: %w", r.Keyframes[i].Id, err)
+		}
+		r.Keyframes[i].Data = data
+	}
+	return nil
+}
+
+// readSegment lee length bytes del origen a partir de offset
+func (r *Rofl) readSegment(offset, length uint32) ([]byte, error) {
+	if r.source == nil {
+		return nil, ErrNoSource
+	}
+	if int64(offset)+int64(length) > r.size {
+		return nil, fmt.Errorf("segmento fuera de rango: offset=%d, longitud=%d, tamaño del archivo=%d", offset, length, r.size)
+	}
+	data := make([]byte, length)
+	n, err := r.source.ReadAt(data, int64(offset))
+	if n == len(data) {
+		return data, nil
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, fmt.Errorf("error leyendo segmento en offset %d: %w", offset, err)
+}
@@ -0,0 +1,265 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Team identifica el lado del mapa de un jugador según el valor de TEAM
+type Team int
+
+const (
+	TeamUnknown Team = 0
+	TeamBlue    Team = 100
+	TeamRed     Team = 200
+)
+
+// String devuelve el nombre del equipo
+func (t Team) String() string {
+	switch t {
+	case TeamBlue:
+		return "blue"
+	case TeamRed:
+		return "red"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseTeam convierte el valor de TEAM ("100" o "200") en un Team
+func ParseTeam(s string) (Team, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return TeamUnknown, err
+	}
+	switch team := Team(n); team {
+	case TeamBlue, TeamRed:
+		return team, nil
+	default:
+		return TeamUnknown, fmt.Errorf("equipo desconocido %d", n)
+	}
+}
+
+// Position es la posición de un jugador tal y como aparece en INDIVIDUAL_POSITION y TEAM_POSITION
+type Position string
+
+const (
+	PositionNone    Position = ""
+	PositionTop     Position = "TOP"
+	PositionJungle  Position = "JUNGLE"
+	PositionMiddle  Position = "MIDDLE"
+	PositionBottom  Position = "BOTTOM"
+	PositionUtility Position = "UTILITY"
+)
+
+// ParsePosition convierte una posición en Position. "Invalid" y el string vacío, que aparecen
+// en modos sin posiciones como ARAM, se convierten en PositionNone.
+func ParsePosition(s string) (Position, error) {
+	switch p := Position(strings.ToUpper(s)); p {
+	case PositionTop, PositionJungle, PositionMiddle, PositionBottom, PositionUtility:
+		return p, nil
+	case PositionNone, "INVALID":
+		return PositionNone, nil
+	default:
+		return PositionNone, fmt.Errorf("posición desconocida %q", s)
+	}
+}
+
+// PlayerStats es la versión tipada de las estadísticas de un jugador. Los contadores son enteros,
+// los indicadores booleanos, TEAM y las posiciones son enums y los tiempos son time.Duration.
+// Las etiquetas json indican la clave de statsJson de la que se lee cada campo; las duraciones
+// vienen en segundos. Se serializa a JSON en el mismo formato que statsJson (todos los valores
+// como string), incluidas las claves guardadas en Extra.
+type PlayerStats struct {
+	// Identidad
+	ID             int64  `json:"ID"`
+	PUUID          string `json:"PUUID"`
+	SummonerID     string `json:"SUMMONER_ID"`
+	Name           string `json:"NAME"`
+	RiotIDGameName string `json:"RIOT_ID_GAME_NAME"`
+	RiotIDTagLine  string `json:"RIOT_ID_TAG_LINE"`
+	Skin           string `json:"SKIN"`
+
+	// Equipo y posición
+	Team                   Team     `json:"TEAM"`
+	IndividualPosition     Position `json:"INDIVIDUAL_POSITION"`
+	TeamPosition           Position `json:"TEAM_POSITION"`
+	PlayerPosition         int      `json:"PLAYER_POSITION"`
+	PlayerRole             int      `json:"PLAYER_ROLE"`
+	PlayerSubteam          int      `json:"PLAYER_SUBTEAM"`
+	PlayerSubteamPlacement int      `json:"PLAYER_SUBTEAM_PLACEMENT"`
+
+	// Resultado
+	Win                         bool `json:"WIN"`
+	GameEndedInSurrender        bool `json:"GAME_ENDED_IN_SURRENDER"`
+	GameEndedInEarlySurrender   bool `json:"GAME_ENDED_IN_EARLY_SURRENDER"`
+	TeamEarlySurrendered        bool `json:"TEAM_EARLY_SURRENDERED"`
+	WasAfk                      bool `json:"WAS_AFK"`
+	WasAfkAfterFailedSurrender  bool `json:"WAS_AFK_AFTER_FAILED_SURRENDER"`
+	WasEarlySurrenderAccomplice bool `json:"WAS_EARLY_SURRENDER_ACCOMPLICE"`
+	WasSurrenderDueToAfk        bool `json:"WAS_SURRENDER_DUE_TO_AFK"`
+	WasLeaver                   bool `json:"WAS_LEAVER"`
+
+	// Combate
+	Level                 int `json:"LEVEL"`
+	Exp                   int `json:"EXP"`
+	ChampionsKilled       int `json:"CHAMPIONS_KILLED"`
+	NumDeaths             int `json:"NUM_DEATHS"`
+	Assists               int `json:"ASSISTS"`
+	DoubleKills           int `json:"DOUBLE_KILLS"`
+	TripleKills           int `json:"TRIPLE_KILLS"`
+	QuadraKills           int `json:"QUADRA_KILLS"`
+	PentaKills            int `json:"PENTA_KILLS"`
+	UnrealKills           int `json:"UNREAL_KILLS"`
+	KillingSprees         int `json:"KILLING_SPREES"`
+	LargestKillingSpree   int `json:"LARGEST_KILLING_SPREE"`
+	LargestMultiKill      int `json:"LARGEST_MULTI_KILL"`
+	LargestCriticalStrike int `json:"LARGEST_CRITICAL_STRIKE"`
+	BountyLevel           int `json:"BOUNTY_LEVEL"`
+
+	// Oro y objetos
+	GoldEarned           int `json:"GOLD_EARNED"`
+	GoldSpent            int `json:"GOLD_SPENT"`
+	ItemsPurchased       int `json:"ITEMS_PURCHASED"`
+	ConsumablesPurchased int `json:"CONSUMABLES_PURCHASED"`
+	Item0                int `json:"ITEM0"`
+	Item1                int `json:"ITEM1"`
+	Item2                int `json:"ITEM2"`
+	Item3                int `json:"ITEM3"`
+	Item4                int `json:"ITEM4"`
+	Item5                int `json:"ITEM5"`
+	Item6                int `json:"ITEM6"`
+
+	// Farmeo
+	MinionsKilled                   int `json:"MINIONS_KILLED"`
+	NeutralMinionsKilled            int `json:"NEUTRAL_MINIONS_KILLED"`
+	NeutralMinionsKilledYourJungle  int `json:"NEUTRAL_MINIONS_KILLED_YOUR_JUNGLE"`
+	NeutralMinionsKilledEnemyJungle int `json:"NEUTRAL_MINIONS_KILLED_ENEMY_JUNGLE"`
+
+	// Daño y curación
+	TotalDamageDealt               int `json:"TOTAL_DAMAGE_DEALT"`
+	TotalDamageDealtToChampions    int `json:"TOTAL_DAMAGE_DEALT_TO_CHAMPIONS"`
+	PhysicalDamageDealtPlayer      int `json:"PHYSICAL_DAMAGE_DEALT_PLAYER"`
+	PhysicalDamageDealtToChampions int `json:"PHYSICAL_DAMAGE_DEALT_TO_CHAMPIONS"`
+	MagicDamageDealtPlayer         int `json:"MAGIC_DAMAGE_DEALT_PLAYER"`
+	MagicDamageDealtToChampions    int `json:"MAGIC_DAMAGE_DEALT_TO_CHAMPIONS"`
+	TrueDamageDealtPlayer          int `json:"TRUE_DAMAGE_DEALT_PLAYER"`
+	TrueDamageDealtToChampions     int `json:"TRUE_DAMAGE_DEALT_TO_CHAMPIONS"`
+	TotalDamageTaken               int `json:"TOTAL_DAMAGE_TAKEN"`
+	PhysicalDamageTaken            int `json:"PHYSICAL_DAMAGE_TAKEN"`
+	MagicDamageTaken               int `json:"MAGIC_DAMAGE_TAKEN"`
+	TrueDamageTaken                int `json:"TRUE_DAMAGE_TAKEN"`
+	TotalDamageSelfMitigated       int `json:"TOTAL_DAMAGE_SELF_MITIGATED"`
+	TotalDamageShieldedOnTeammates int `json:"TOTAL_DAMAGE_SHIELDED_ON_TEAMMATES"`
+	TotalDamageDealtToBuildings    int `json:"TOTAL_DAMAGE_DEALT_TO_BUILDINGS"`
+	TotalDamageDealtToTurrets      int `json:"TOTAL_DAMAGE_DEALT_TO_TURRETS"`
+	TotalDamageDealtToObjectives   int `json:"TOTAL_DAMAGE_DEALT_TO_OBJECTIVES"`
+	TotalHeal                      int `json:"TOTAL_HEAL"`
+	TotalHealOnTeammates           int `json:"TOTAL_HEAL_ON_TEAMMATES"`
+	TotalUnitsHealed               int `json:"TOTAL_UNITS_HEALED"`
+
+	// Visión
+	VisionScore             int `json:"VISION_SCORE"`
+	WardPlaced              int `json:"WARD_PLACED"`
+	WardKilled              int `json:"WARD_KILLED"`
+	WardPlacedDetector      int `json:"WARD_PLACED_DETECTOR"`
+	VisionWardsBoughtInGame int `json:"VISION_WARDS_BOUGHT_IN_GAME"`
+	SightWardsBoughtInGame  int `json:"SIGHT_WARDS_BOUGHT_IN_GAME"`
+
+	// Objetivos
+	TurretsKilled           int `json:"TURRETS_KILLED"`
+	TurretTakedowns         int `json:"TURRET_TAKEDOWNS"`
+	BarracksKilled          int `json:"BARRACKS_KILLED"`
+	BarracksTakedowns       int `json:"BARRACKS_TAKEDOWNS"`
+	HqKilled                int `json:"HQ_KILLED"`
+	HqTakedowns             int `json:"HQ_TAKEDOWNS"`
+	DragonKills             int `json:"DRAGON_KILLS"`
+	BaronKills              int `json:"BARON_KILLS"`
+	RiftHeraldKills         int `json:"RIFT_HERALD_KILLS"`
+	HordeKills              int `json:"HORDE_KILLS"`
+	AtakhanKills            int `json:"ATAKHAN_KILLS"`
+	ObjectivesStolen        int `json:"OBJECTIVES_STOLEN"`
+	ObjectivesStolenAssists int `json:"OBJECTIVES_STOLEN_ASSISTS"`
+	FriendlyTurretLost      int `json:"FRIENDLY_TURRET_LOST"`
+	FriendlyDampenLost      int `json:"FRIENDLY_DAMPEN_LOST"`
+	FriendlyHqLost          int `json:"FRIENDLY_HQ_LOST"`
+
+	// Runas, hechizos y habilidades
+	KeystoneID       int `json:"KEYSTONE_ID"`
+	PerkPrimaryStyle int `json:"PERK_PRIMARY_STYLE"`
+	PerkSubStyle     int `json:"PERK_SUB_STYLE"`
+	Perk0            int `json:"PERK0"`
+	Perk1            int `json:"PERK1"`
+	Perk2            int `json:"PERK2"`
+	Perk3            int `json:"PERK3"`
+	Perk4            int `json:"PERK4"`
+	Perk5            int `json:"PERK5"`
+	StatPerk0        int `json:"STAT_PERK_0"`
+	StatPerk1        int `json:"STAT_PERK_1"`
+	StatPerk2        int `json:"STAT_PERK_2"`
+	SummonerSpell1   int `json:"SUMMONER_SPELL_1"`
+	SummonerSpell2   int `json:"SUMMONER_SPELL_2"`
+	Spell1Cast       int `json:"SPELL1_CAST"`
+	Spell2Cast       int `json:"SPELL2_CAST"`
+	Spell3Cast       int `json:"SPELL3_CAST"`
+	Spell4Cast       int `json:"SPELL4_CAST"`
+	SummonSpell1Cast int `json:"SUMMON_SPELL1_CAST"`
+	SummonSpell2Cast int `json:"SUMMON_SPELL2_CAST"`
+
+	// Arena
+	PlayerAugment1 int `json:"PLAYER_AUGMENT_1"`
+	PlayerAugment2 int `json:"PLAYER_AUGMENT_2"`
+	PlayerAugment3 int `json:"PLAYER_AUGMENT_3"`
+	PlayerAugment4 int `json:"PLAYER_AUGMENT_4"`
+	PlayerAugment5 int `json:"PLAYER_AUGMENT_5"`
+	PlayerAugment6 int `json:"PLAYER_AUGMENT_6"`
+
+	// Tiempos
+	TimePlayed                            time.Duration `json:"TIME_PLAYED"`
+	TotalTimeSpentDead                    time.Duration `json:"TOTAL_TIME_SPENT_DEAD"`
+	LongestTimeSpentLiving                time.Duration `json:"LONGEST_TIME_SPENT_LIVING"`
+	TimeCcingOthers                       time.Duration `json:"TIME_CCING_OTHERS"`
+	TotalTimeCrowdControlDealt            time.Duration `json:"TOTAL_TIME_CROWD_CONTROL_DEALT"`
+	TotalTimeCrowdControlDealtToChampions time.Duration `json:"TOTAL_TIME_CROWD_CONTROL_DEALT_TO_CHAMPIONS"`
+	TimeSpentDisconnected                 time.Duration `json:"TIME_SPENT_DISCONNECTED"`
+	TimeOfFromLastDisconnect              time.Duration `json:"TIME_OF_FROM_LAST_DISCONNECT"`
+	LastTakedownTime                      time.Duration `json:"LAST_TAKEDOWN_TIME"`
+
+	// Pings
+	AllInPings         int `json:"ALL_IN_PINGS"`
+	AssistMePings      int `json:"ASSIST_ME_PINGS"`
+	BasicPings         int `json:"BASIC_PINGS"`
+	CommandPings       int `json:"COMMAND_PINGS"`
+	DangerPings        int `json:"DANGER_PINGS"`
+	EnemyMissingPings  int `json:"ENEMY_MISSING_PINGS"`
+	EnemyVisionPings   int `json:"ENEMY_VISION_PINGS"`
+	GetBackPings       int `json:"GET_BACK_PINGS"`
+	HoldPings          int `json:"HOLD_PINGS"`
+	NeedVisionPings    int `json:"NEED_VISION_PINGS"`
+	OnMyWayPings       int `json:"ON_MY_WAY_PINGS"`
+	PushPings          int `json:"PUSH_PINGS"`
+	RetreatPings       int `json:"RETREAT_PINGS"`
+	VisionClearedPings int `json:"VISION_CLEARED_PINGS"`
+
+	// Extra guarda, sin convertir, las claves de statsJson que no tienen campo propio
+	// (por ejemplo Missions_* o Event_*), para no perder datos de parches nuevos
+	Extra map[string]string `json:"-"`
+
+	// absent guarda las claves con campo propio que no venían en statsJson, para no escribirlas al serializar
+	absent map[string]bool
+	// original guarda los valores de statsJson que la conversión normaliza ("Invalid" en las
+	// posiciones, "007"...) o que no se pudieron convertir, para escribirlos tal cual al serializar
+	original map[string]originalStat
+	// jsonValues guarda el texto JSON de los valores de statsJson que no eran strings, para
+	// escribirlos con su tipo original al serializar
+	jsonValues map[string]string
+}
+
+// originalStat es un valor de statsJson junto con el valor que se escribiría desde el campo tipado
+// justo después de convertirlo; si el campo cambia después, se escribe el valor nuevo
+type originalStat struct {
+	value     string
+	formatted string
+}
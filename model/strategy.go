@@ -0,0 +1,27 @@
+package model
+
+// MetadataStrategy describe el método usado para localizar el bloque de metadata JSON
+type MetadataStrategy int
+
+const (
+	// MetadataFromHeader indica que la metadata se leyó usando Lengths.MetadataOffset y Lengths.Metadata
+	MetadataFromHeader MetadataStrategy = iota
+	// MetadataFromScan indica que la cabecera era inconsistente y la metadata se buscó por contenido
+	MetadataFromScan
+	// MetadataFromFooter indica que la metadata se leyó usando la longitud guardada al final del archivo (ROFL2)
+	MetadataFromFooter
+)
+
+// String devuelve el nombre legible de la estrategia
+func (s MetadataStrategy) String() string {
+	switch s {
+	case MetadataFromHeader:
+		return "header"
+	case MetadataFromScan:
+		return "scan"
+	case MetadataFromFooter:
+		return "footer"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,215 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidVersion indica que una versión o un rango de parches no tiene el formato esperado
+var ErrInvalidVersion = errors.New("versión inválida")
+
+// GameVersion es la versión del cliente con la que se jugó la partida, como 15.4.655.8470:
+// Major y Minor forman el parche (15.4); Build y Revision identifican la compilación
+type GameVersion struct {
+	Major    int `json:"major"`
+	Minor    int `json:"minor"`
+	Build    int `json:"build"`
+	Revision int `json:"revision"`
+}
+
+// ParseGameVersion lee una versión de dos a cuatro números separados por puntos, como 15.4,
+// 15.4.1 o 15.4.655.8470. Los números que faltan quedan a cero.
+func ParseGameVersion(s string) (GameVersion, error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	if len(parts) < 2 || len(parts) > 4 {
+		return GameVersion{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	nums := make([]int, 4)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return GameVersion{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+		}
+		nums[i] = n
+	}
+	return GameVersion{Major: nums[0], Minor: nums[1], Build: nums[2], Revision: nums[3]}, nil
+}
+
+// IsZero indica si la versión no se conoce, por ejemplo porque gameVersion venía vacío
+func (v GameVersion) IsZero() bool {
+	return v == GameVersion{}
+}
+
+// String devuelve la versión completa, como 15.4.655.8470, o una cadena vacía si es cero
+func (v GameVersion) String() string {
+	if v.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Build, v.Revision)
+}
+
+// Patch devuelve el parche, como 15.4, o una cadena vacía si la versión es cero
+func (v GameVersion) Patch() string {
+	if v.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Compare devuelve -1, 0 o 1 según v sea anterior, igual o posterior a o
+func (v GameVersion) Compare(o GameVersion) int {
+	if c := v.ComparePatch(o); c != 0 {
+		return c
+	}
+	if c := compareInt(v.Build, o.Build); c != 0 {
+		return c
+	}
+	return compareInt(v.Revision, o.Revision)
+}
+
+// ComparePatch compara solo el parche (Major y Minor), de modo que 15.4.655.8470 y 15.4.1 son iguales
+func (v GameVersion) ComparePatch(o GameVersion) int {
+	if c := compareInt(v.Major, o.Major); c != 0 {
+		return c
+	}
+	return compareInt(v.Minor, o.Minor)
+}
+
+// SamePatch indica si v y o son del mismo parche
+func (v GameVersion) SamePatch(o GameVersion) bool {
+	return v.ComparePatch(o) == 0
+}
+
+// Before indica si v es anterior a o
+func (v GameVersion) Before(o GameVersion) bool {
+	return v.Compare(o) < 0
+}
+
+// After indica si v es posterior a o
+func (v GameVersion) After(o GameVersion) bool {
+	return v.Compare(o) > 0
+}
+
+// compareInt devuelve -1, 0 o 1 según a sea menor, igual o mayor que b
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// unversionedRange es el texto del intervalo que solo contiene los replays sin versión
+const unversionedRange = "unversioned"
+
+// VersionRange es un intervalo cerrado de parches: contiene las versiones cuyo parche está entre
+// From y To, ambos incluidos, sin tener en cuenta Build ni Revision. Un extremo cero deja el
+// intervalo abierto por ese lado, así que el VersionRange cero contiene todas las versiones.
+// Con Unversioned el intervalo solo contiene la versión cero, la de los replays sin gameVersion,
+// y se ignoran From y To.
+type VersionRange struct {
+	From        GameVersion `json:"from"`
+	To          GameVersion `json:"to"`
+	Unversioned bool        `json:"unversioned,omitempty"`
+}
+
+// UnversionedRange devuelve el intervalo que solo contiene los replays sin versión
+func UnversionedRange() VersionRange {
+	return VersionRange{Unversioned: true}
+}
+
+// PatchRange devuelve el intervalo de un único parche, el de v
+func PatchRange(v GameVersion) VersionRange {
+	p := GameVersion{Major: v.Major, Minor: v.Minor}
+	return VersionRange{From: p, To: p}
+}
+
+// ParseVersionRange lee un intervalo de parches: un parche suelto (15.4), dos extremos separados
+// por un guion (13.1-14.24), un único extremo con el otro lado abierto (14.1- o -13.24) o
+// "unversioned" para los replays sin versión
+func ParseVersionRange(s string) (VersionRange, error) {
+	s = strings.TrimSpace(s)
+	if s == unversionedRange {
+		return UnversionedRange(), nil
+	}
+	from, to, isRange := strings.Cut(s, "-")
+	if !isRange {
+		v, err := ParseGameVersion(s)
+		if err != nil {
+			return VersionRange{}, err
+		}
+		return PatchRange(v), nil
+	}
+	var r VersionRange
+	var err error
+	if from = strings.TrimSpace(from); from != "" {
+		if r.From, err = ParseGameVersion(from); err != nil {
+			return VersionRange{}, err
+		}
+	}
+	if to = strings.TrimSpace(to); to != "" {
+		if r.To, err = ParseGameVersion(to); err != nil {
+			return VersionRange{}, err
+		}
+	}
+	if from == "" && to == "" {
+		return VersionRange{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	if !r.From.IsZero() && !r.To.IsZero() && r.From.ComparePatch(r.To) > 0 {
+		return VersionRange{}, fmt.Errorf("%w: %q empieza después de terminar", ErrInvalidVersion, s)
+	}
+	return r, nil
+}
+
+// Contains indica si el parche de v está dentro del intervalo. Una versión cero solo está en el
+// VersionRange cero y en los intervalos Unversioned.
+func (r VersionRange) Contains(v GameVersion) bool {
+	if r.Unversioned || v.IsZero() {
+		return v.IsZero() && (r.Unversioned || r == VersionRange{})
+	}
+	if !r.From.IsZero() && v.ComparePatch(r.From) < 0 {
+		return false
+	}
+	if !r.To.IsZero() && v.ComparePatch(r.To) > 0 {
+		return false
+	}
+	return true
+}
+
+// String devuelve el intervalo en el formato de ParseVersionRange
+func (r VersionRange) String() string {
+	if r.Unversioned {
+		return unversionedRange
+	}
+	if r == (VersionRange{}) {
+		return ""
+	}
+	if !r.From.IsZero() && r.From.SamePatch(r.To) {
+		return r.From.Patch()
+	}
+	return r.From.Patch() + "-" + r.To.Patch()
+}
+
+// MarshalText codifica el intervalo como en String, para guardarlo como texto en JSON
+func (r VersionRange) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText lee el intervalo con ParseVersionRange. El texto vacío es el VersionRange cero.
+func (r *VersionRange) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = VersionRange{}
+		return nil
+	}
+	v, err := ParseVersionRange(string(text))
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
@@ -0,0 +1,84 @@
+package roflparser
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/pointedsec/rofl-parser/schema"
+)
+
+// Option configura el comportamiento del parser
+type Option func(*config)
+
+// config reúne las opciones de un parseo
+type config struct {
+	logger           *slog.Logger
+	strict           bool
+	strictValidation bool
+	metadataOnly     bool
+	schemas          *schema.Registry
+}
+
+// newConfig aplica las opciones sobre la configuración por defecto, que no registra nada
+func newConfig(opts []Option) *config {
+	cfg := &config{logger: slog.New(slog.DiscardHandler), schemas: schema.Default()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithLogger registra el progreso del parseo en logger. Los pasos del parseo se registran
+// con nivel Debug y las recuperaciones (por ejemplo, buscar la metadata por contenido) con nivel Warn.
+// Los mensajes son claves estables en inglés ("metadata located", "payload header read"...) y los
+// datos van en atributos, de modo que se pueden filtrar sin depender del idioma.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// WithStrict desactiva las recuperaciones: si la cabecera es inconsistente o StatsJSON no se puede
+// convertir a Metadata.Stats, el parseo falla en vez de continuar con un aviso
+func WithStrict() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithStrictValidation hace que el parseo devuelva un *model.ValidationError si el replay tiene
+// campos faltantes o extra respecto al esquema de su parche, valores de statsJson que no se pueden
+// convertir, un número de jugadores que no encaja en ninguna cola o PUUID repetidos. En ese caso
+// se devuelven también el replay y los errores de validación, para poder inspeccionarlo o apartarlo.
+func WithStrictValidation() Option {
+	return func(c *config) {
+		c.strictValidation = true
+	}
+}
+
+// WithMetadataOnly omite la lectura del payload header y del índice de segmentos
+func WithMetadataOnly() Option {
+	return func(c *config) {
+		c.metadataOnly = true
+	}
+}
+
+// WithSchemas valida la metadata y las estadísticas con los esquemas de registry en vez de con schema.Default()
+func WithSchemas(registry *schema.Registry) Option {
+	return func(c *config) {
+		if registry != nil {
+			c.schemas = registry
+		}
+	}
+}
+
+// withVerbose reproduce el parámetro verbose de las funciones New*: registra todo en la salida estándar
+func withVerbose(verbose bool) Option {
+	return func(c *config) {
+		if verbose {
+			c.logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		}
+	}
+}
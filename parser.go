@@ -4,230 +4,247 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"strings"
 
 	"github.com/pointedsec/rofl-parser/model"
+	"github.com/pointedsec/rofl-parser/schema"
 )
 
-// NewFromReader permite parsear el archivo desde un io.Reader
-func NewFromReader(reader io.Reader, verbose bool) (*model.Rofl, error) {
-	// Lee todo el contenido en memoria
-	allBytes, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, fmt.Errorf("error leyendo datos: %w", err)
-	}
-	r, _, _, err := parseRoflBytes(allBytes, verbose)
-	return r, err
-}
-
-// NewFromReaderFull permite parsear el archivo desde un io.Reader y devuelve los errores completos
-func NewFromReaderFull(reader io.Reader, verbose bool) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
-	allBytes, err := io.ReadAll(reader)
+// Parse parsea un archivo .rofl leído por completo desde src y devuelve también los errores de validación
+func Parse(src io.Reader, opts ...Option) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
+	allBytes, err := io.ReadAll(src)
 	if err != nil {
 		return nil, nil, nil, fmt.Errorf("error leyendo datos: %w", err)
 	}
-	return parseRoflBytes(allBytes, verbose)
+	return parseRofl(newBytesSource(allBytes), newConfig(opts))
 }
 
-// New abre y parsea un archivo .rofl desde la ruta dada
-func New(path string, verbose bool) (*model.Rofl, error) {
+// ParseFile abre y parsea el archivo .rofl de la ruta dada
+func ParseFile(path string, opts ...Option) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
 	file, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("error abriendo archivo: %w", err)
+		return nil, nil, nil, fmt.Errorf("error abriendo archivo: %w", err)
 	}
 	defer file.Close()
 
 	allBytes, err := io.ReadAll(file)
 	if err != nil {
-		return nil, fmt.Errorf("error leyendo archivo completo: %w", err)
+		return nil, nil, nil, fmt.Errorf("error leyendo archivo completo: %w", err)
 	}
-	r, _, _, err := parseRoflBytes(allBytes, verbose)
+	return parseRofl(newBytesSource(allBytes), newConfig(opts))
+}
+
+// NewFromReaderAt parsea un archivo .rofl con acceso aleatorio, sin cargarlo entero en memoria.
+// Solo se leen la cabecera, la metadata y el índice de segmentos; los datos de chunks y keyframes
+// se leen bajo demanda con Rofl.ChunkData, Rofl.KeyframeData o Rofl.LoadData, por lo que r debe
+// seguir abierto mientras se usen.
+func NewFromReaderAt(r io.ReaderAt, size int64, opts ...Option) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
+	return parseRofl(newReaderAtSource(r, size), newConfig(opts))
+}
+
+// NewFromReader permite parsear el archivo desde un io.Reader
+func NewFromReader(reader io.Reader, verbose bool) (*model.Rofl, error) {
+	r, _, _, err := Parse(reader, withVerbose(verbose))
+	return r, err
+}
+
+// NewFromReaderFull permite parsear el archivo desde un io.Reader y devuelve los errores completos
+func NewFromReaderFull(reader io.Reader, verbose bool) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
+	return Parse(reader, withVerbose(verbose))
+}
+
+// New abre y parsea un archivo .rofl desde la ruta dada
+func New(path string, verbose bool) (*model.Rofl, error) {
+	r, _, _, err := ParseFile(path, withVerbose(verbose))
 	return r, err
 }
 
 // NewFull abre y parsea un archivo .rofl y devuelve los errores completos
 func NewFull(path string, verbose bool) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error abriendo archivo: %w", err)
+	return ParseFile(path, withVerbose(verbose))
+}
+
+// parseRofl contiene la lógica principal del parseo
+func parseRofl(src *source, cfg *config) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
+	log := cfg.logger
+	r := &model.Rofl{}
+	// Si el archivo está en memoria los datos de los segmentos ya se guardan en cada Chunk y
+	// Keyframe, así que no se asocia el origen: con WithMetadataOnly el Rofl no retiene el archivo
+	if !src.inMemory() {
+		r.SetSource(src.r, src.size)
 	}
-	defer file.Close()
 
-	allBytes, err := io.ReadAll(file)
+	header, err := src.section(0, min(headerSize, src.size))
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error leyendo archivo completo: %w", err)
+		return nil, nil, nil, newParseError(SectionMagic, 0, err)
 	}
-	return parseRoflBytes(allBytes, verbose)
-}
+	buf := bytes.NewReader(header)
 
-// parseRoflBytes contiene la lógica principal del parseo
-func parseRoflBytes(allBytes []byte, verbose bool) (*model.Rofl, *model.MetadataValidationError, []model.PlayerStatsValidationError, error) {
-	r := &model.Rofl{}
-	buf := bytes.NewReader(allBytes)
-
-	// --- Leer Magic y Signature ---
+	// --- Leer Magic y detectar el formato ---
 	if err := binary.Read(buf, binary.LittleEndian, &r.Magic); err != nil {
-		return nil, nil, nil, fmt.Errorf("error leyendo magic: %w", err)
-	}
-	if !bytes.HasPrefix(r.Magic[:], []byte("RIOT")) {
-		return nil, nil, nil, fmt.Errorf("magic number inválido: %v", r.Magic)
+		return nil, nil, nil, newParseError(SectionMagic, 0, fmt.Errorf("%w: %v", ErrTruncatedHeader, err))
 	}
-	if verbose {
-		fmt.Println("Magic OK:", string(r.Magic[:4]))
+	format, err := detectFormat(r.Magic)
+	if err != nil {
+		return nil, nil, nil, err
 	}
+	r.FormatVersion = format
+	log.Debug("magic ok", "format", format.String())
 
-	if err := binary.Read(buf, binary.LittleEndian, &r.Signature); err != nil {
-		return nil, nil, nil, fmt.Errorf("error leyendo signature: %w", err)
-	}
-	if verbose {
-		fmt.Printf("Signature: %x\n", r.Signature[:16])
-	}
+	var metaBytes []byte
+	var metaOffset int64
+	switch format {
+	case model.FormatV1:
+		if err := binary.Read(buf, binary.LittleEndian, &r.Signature); err != nil {
+			return nil, nil, nil, newParseError(SectionSignature, magicSize, fmt.Errorf("%w: %v", ErrTruncatedHeader, err))
+		}
+		log.Debug("signature read", "prefix", fmt.Sprintf("%x", r.Signature[:16]))
 
-	// --- Leer longitudes y offsets ---
-	if err := binary.Read(buf, binary.LittleEndian, &r.Lengths); err != nil {
-		return nil, nil, nil, fmt.Errorf("error leyendo lengths: %w", err)
-	}
+		// --- Leer longitudes y offsets ---
+		if err := binary.Read(buf, binary.LittleEndian, &r.Lengths); err != nil {
+			return nil, nil, nil, newParseError(SectionLengths, magicSize+int64(len(r.Signature)), fmt.Errorf("%w: %v", ErrTruncatedHeader, err))
+		}
 
-	// Busca el inicio del JSON por la secuencia específica
-	jsonStartSeq := []byte(`{"gameLength":`)
-	start := bytes.Index(allBytes, jsonStartSeq)
-	if start == -1 {
-		return nil, nil, nil, fmt.Errorf("no se encontró el inicio del bloque JSON con '\"gameLength\":'")
+		// --- Extraer el bloque JSON de metadata ---
+		metaBytes, r.MetadataStrategy, metaOffset, err = extractMetadata(src, r.Lengths)
+	case model.FormatV2:
+		// ROFL2 no tiene tabla de offsets: la metadata está al final del archivo
+		metaBytes, r.MetadataStrategy, metaOffset, err = extractMetadataV2(src)
 	}
-
-	// Busca el cierre del JSON correspondiente
-	end := start
-	bracketCount := 0
-	foundStart := false
-	for i := start; i < len(allBytes); i++ {
-		if allBytes[i] == '{' {
-			bracketCount++
-			foundStart = true
-		} else if allBytes[i] == '}' {
-			bracketCount--
-			if foundStart && bracketCount == 0 {
-				end = i
-				break
-			}
-		}
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	if end <= start {
-		return nil, nil, nil, fmt.Errorf("no se encontró el final del bloque JSON")
+	if r.MetadataStrategy == model.MetadataFromScan {
+		if cfg.strict {
+			return nil, nil, nil, newParseError(SectionLengths, magicSize+int64(len(r.Signature)),
+				fmt.Errorf("%w: la metadata no está en offset=%d, longitud=%d", ErrInconsistentHeader, r.Lengths.MetadataOffset, r.Lengths.Metadata))
+		}
+		log.Warn("inconsistent header, metadata found by scan", "bytes", len(metaBytes))
+	} else {
+		log.Debug("metadata located", "strategy", r.MetadataStrategy.String(), "bytes", len(metaBytes))
 	}
-	metaBytes := allBytes[start : end+1]
 
 	// --- Validación y análisis del JSON ---
 	var metaMap map[string]interface{}
 	if err := json.Unmarshal(metaBytes, &metaMap); err != nil {
-		return nil, nil, nil, fmt.Errorf("error parseando metadata JSON: %w", err)
+		return nil, nil, nil, newParseError(SectionMetadata, metaOffset, fmt.Errorf("%w: %v", ErrMetadataMalformed, err))
 	}
 
-	expectedFields := getJSONFields(reflect.TypeOf(model.MetadataJson{}))
-	missingFields := []string{}
-	extraFields := []string{}
-
-	for _, field := range expectedFields {
-		if _, ok := metaMap[field]; !ok {
-			missingFields = append(missingFields, field)
-		}
-	}
-	for key := range metaMap {
-		found := false
-		for _, field := range expectedFields {
-			if key == field {
-				found = true
-				break
-			}
-		}
-		if !found {
-			extraFields = append(extraFields, key)
+	// La versión se lee antes de validar para elegir el esquema de su parche. Los replays ROFL2
+	// no siempre rellenan gameVersion; en ese caso la versión queda a cero.
+	if gameVersion, _ := metaMap["gameVersion"].(string); gameVersion != "" {
+		version, err := model.ParseGameVersion(gameVersion)
+		if err != nil {
+			log.Warn("invalid gameVersion", "gameVersion", gameVersion, "error", err)
 		}
+		r.Version = version
 	}
+	sch := cfg.schemaFor(r.Version)
 
-	metadataErr := &model.MetadataValidationError{
-		MissingFields: missingFields,
-		ExtraFields:   extraFields,
-	}
+	metaValidation := sch.ValidateMetadata(metaMap)
+	metadataErr := &metaValidation
 
-	if verbose {
-		fmt.Printf("Campos faltantes en MetadataJson: %d\n", len(missingFields))
-		if len(missingFields) > 0 {
-			fmt.Printf("Faltan: %v\n", missingFields)
-		}
-		if len(extraFields) > 0 {
-			fmt.Printf("Campos extra en JSON: %v\n", extraFields)
-		}
-	}
+	log.Debug("metadata validated", "schema", metadataErr.Schema, "missingFields", metadataErr.MissingFields, "extraFields", metadataErr.ExtraFields)
 
 	if err := json.Unmarshal(metaBytes, &r.Metadata); err != nil {
-		return nil, metadataErr, nil, fmt.Errorf("error parseando MetadataJson: %w", err)
+		return nil, metadataErr, nil, newParseError(SectionMetadata, metaOffset, fmt.Errorf("%w: %v", ErrMetadataMalformed, err))
 	}
+	// Se copia para no retener el archivo completo cuando está en memoria
+	r.RawMetadata = bytes.Clone(metaBytes)
 
 	var statsErrs []model.PlayerStatsValidationError
 
 	if r.Metadata.StatsJSON != "" {
 		var statsArr []map[string]interface{}
 		if err := json.Unmarshal([]byte(r.Metadata.StatsJSON), &statsArr); err != nil {
-			return nil, metadataErr, nil, fmt.Errorf("error parseando stats JSON: %w", err)
+			return nil, metadataErr, nil, newParseError(SectionStats, metaOffset, fmt.Errorf("%w: %v", ErrStatsMalformed, err))
 		}
-		expectedStatsFields := getJSONFields(reflect.TypeOf(model.PlayerStatsJson{}))
 		for idx, stats := range statsArr {
-			missingStats := []string{}
-			extraStats := []string{}
-			for _, field := range expectedStatsFields {
-				if _, ok := stats[field]; !ok {
-					missingStats = append(missingStats, field)
-				}
-			}
-			for key := range stats {
-				found := false
-				for _, field := range expectedStatsFields {
-					if key == field {
-						found = true
-						break
-					}
-				}
-				if !found {
-					extraStats = append(extraStats, key)
-				}
-			}
-			statsErrs = append(statsErrs, model.PlayerStatsValidationError{
-				PlayerIndex:   idx,
-				MissingFields: missingStats,
-				ExtraFields:   extraStats,
-			})
-			if verbose {
-				fmt.Printf("StatsJSON jugador %d: faltan %d campos, extras: %d\n", idx, len(missingStats), len(extraStats))
-				if len(missingStats) > 0 {
-					fmt.Printf("Faltan: %v\n", missingStats)
-				}
-				if len(extraStats) > 0 {
-					fmt.Printf("Extras: %v\n", extraStats)
-				}
-			}
+			statsErr := sch.ValidateStats(idx, stats)
+			statsErrs = append(statsErrs, statsErr)
+			log.Debug("stats validated", "playerIndex", idx, "missingFields", statsErr.MissingFields, "extraFields", statsErr.ExtraFields)
 		}
+		// Los valores que no se pueden convertir quedan a cero y no impiden deducir el modo
+		players, _ := ParsePlayerStats(r.Metadata.StatsJSON)
+		r.GameMode = model.InferGameMode(players)
+		log.Debug("game mode inferred", "mode", r.GameMode.Mode.String(), "confidence", r.GameMode.Confidence)
 		if err := json.Unmarshal([]byte(r.Metadata.StatsJSON), &r.Metadata.Stats); err != nil {
-			fmt.Printf("Advertencia: no se pudo parsear StatsJSON a PlayerStatsJson: %v\n", err)
+			if cfg.strict {
+				return nil, metadataErr, statsErrs, newParseError(SectionStats, metaOffset, fmt.Errorf("%w: %v", ErrStatsMalformed, err))
+			}
+			log.Warn("statsJson not decoded", "error", err)
 		}
 	}
 
-	if verbose {
-		fmt.Printf("Metadata cargada: Version=%s, GameLength=%d\n", r.Metadata.GameVersion, r.Metadata.GameLength)
+	log.Debug("metadata loaded", "gameVersion", r.Metadata.GameVersion, "patch", r.Version.Patch(), "gameLength", r.Metadata.GameLength)
+
+	// --- Leer el payload ---
+	if cfg.metadataOnly {
+		r.PayloadSkipped = true
+		log.Debug("metadata only, payload skipped")
+	} else if format == model.FormatV2 {
+		r.Chunks, err = parseSegmentsV2(src, metaOffset)
+		if err != nil {
+			return nil, metadataErr, statsErrs, err
+		}
+		log.Debug("zstd segments found", "chunks", len(r.Chunks))
+	} else if r.MetadataStrategy == model.MetadataFromHeader {
+		// Si la metadata no se pudo localizar con la cabecera, sus offsets no son fiables
+		payloadHeader, err := parsePayloadHeader(src, r.Lengths)
+		if err != nil {
+			return nil, metadataErr, statsErrs, err
+		}
+		r.PayloadHeader = payloadHeader
+		log.Debug("payload header read", "gameId", payloadHeader.GameId, "chunks", payloadHeader.ChunkCount, "keyframes", payloadHeader.KeyframeCount)
+
+		chunks, keyframes, err := parseSegments(src, r.Lengths, payloadHeader)
+		if err != nil {
+			return nil, metadataErr, statsErrs, err
+		}
+		r.Chunks = chunks
+		r.Keyframes = keyframes
+	} else {
+		r.PayloadSkipped = true
+		log.Warn("inconsistent header, payload skipped")
 	}
 
+	if cfg.strictValidation {
+		if err := validateStrict(r, sch != &structSchema, metadataErr, statsErrs); err != nil {
+			log.Debug("strict validation failed", "error", err)
+			return r, metadataErr, statsErrs, err
+		}
+	}
 	return r, metadataErr, statsErrs, nil
 }
 
-// getJSONFields devuelve los nombres de los campos JSON de una estructura
+// structSchema es el esquema que se usa cuando no hay ninguno registrado para la versión del
+// replay: las claves de model.MetadataJson y model.PlayerStatsJson
+var structSchema = schema.Schema{
+	Metadata: getJSONFields(reflect.TypeOf(model.MetadataJson{})),
+	Stats:    getJSONFields(reflect.TypeOf(model.PlayerStatsJson{})),
+}
+
+// schemaFor elige el esquema registrado para el parche de version o, si no hay ninguno, structSchema
+func (c *config) schemaFor(version model.GameVersion) *schema.Schema {
+	if s, ok := c.schemas.Lookup(version); ok {
+		return s
+	}
+	return &structSchema
+}
+
+// getJSONFields devuelve los nombres de los campos JSON obligatorios de una estructura.
+// Los campos con omitempty son opcionales y no se incluyen.
 func getJSONFields(t reflect.Type) []string {
 	fields := []string{}
 	for i := 0; i < t.NumField(); i++ {
-		tag := t.Field(i).Tag.Get("json")
-		if tag != "" && tag != "-" {
-			fields = append(fields, tag)
+		name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && !strings.Contains(opts, "omitempty") {
+			fields = append(fields, name)
 		}
 	}
 	return fields
@@ -244,6 +261,44 @@ func ParseStatsJsonToPlayerStatsJson(statsJson string) []model.PlayerStatsJson {
 	return playerStats
 }
 
+// ParsePlayerStats convierte statsJson en la versión tipada de las estadísticas de cada jugador.
+// Si algún valor no se puede convertir, o no es un string, se devuelven igualmente todos los
+// jugadores, junto con un error que agrupa un *model.StatsConversionError por cada jugador afectado.
+func ParsePlayerStats(statsJson string) ([]model.PlayerStats, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(statsJson), &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStatsMalformed, err)
+	}
+
+	players := make([]model.PlayerStats, len(raw))
+	var errs []error
+	for idx, stats := range raw {
+		player, err := model.DecodePlayerStats(stats)
+		if convErr, ok := err.(*model.StatsConversionError); ok {
+			convErr.PlayerIndex = idx
+			errs = append(errs, convErr)
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: jugador %d: %v", ErrStatsMalformed, idx, err)
+		}
+		players[idx] = player
+	}
+	return players, errors.Join(errs...)
+}
+
+// Summarize resume la partida de meta: los totales de cada equipo y el ganador. Como en
+// ParsePlayerStats, si algún valor no se puede convertir se devuelve igualmente el resumen junto con el error.
+func Summarize(meta model.MetadataJson) (model.Game, error) {
+	var players []model.PlayerStats
+	var err error
+	if meta.StatsJSON != "" {
+		players, err = ParsePlayerStats(meta.StatsJSON)
+		if players == nil {
+			return model.Game{}, err
+		}
+	}
+	return model.NewGame(meta, players), err
+}
+
 // ParseJSON es una función genérica para parsear JSON en una estructura dada
 func ParseJSON[T any](jsonStr string, out *T) error {
 	return json.Unmarshal([]byte(jsonStr), out)
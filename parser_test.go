@@ -0,0 +1,23 @@
+package roflparser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+func TestParsePlayerStatsNonStringValue(t *testing.T) {
+	players, err := ParsePlayerStats(`[{"TEAM":"100","GOLD_EARNED":1500},{"TEAM":"200","GOLD_EARNED":"900"}]`)
+	if errors.Is(err, ErrStatsMalformed) {
+		t.Fatalf("un valor que no es string no debe invalidar statsJson: %v", err)
+	}
+	if len(players) != 2 || players[0].Team != model.TeamBlue || players[1].GoldEarned != 900 {
+		t.Fatalf("jugadores inesperados: %+v", players)
+	}
+	var convErr *model.StatsConversionError
+	if !errors.As(err, &convErr) || convErr.PlayerIndex != 0 || len(convErr.Fields) != 1 ||
+		convErr.Fields[0].Key != "GOLD_EARNED" || !errors.Is(convErr.Fields[0].Err, model.ErrNotString) {
+		t.Fatalf("se esperaba un StatFieldError de GOLD_EARNED con ErrNotString: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package roflparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// payloadHeaderFixedSize es el tamaño de la parte fija del payload header, sin la clave de cifrado
+const payloadHeaderFixedSize = 34
+
+// parsePayloadHeader lee el payload header situado en Lengths.PayloadHeaderOffset,
+// incluida la clave de cifrado en base64 de longitud variable
+func parsePayloadHeader(src *source, lengths model.Lengths) (model.PayloadHeader, error) {
+	var ph model.PayloadHeader
+
+	start := int64(lengths.PayloadHeaderOffset)
+	length := int64(lengths.PayloadHeader)
+	if length < payloadHeaderFixedSize || !src.inRange(start, length) {
+		return ph, newParseError(SectionPayloadHeader, start, fmt.Errorf("%w: payload header fuera de rango: longitud=%d, tamaño del archivo=%d",
+			ErrPayloadMalformed, length, src.size))
+	}
+	section, err := src.section(start, length)
+	if err != nil {
+		return ph, newParseError(SectionPayloadHeader, start, err)
+	}
+	buf := bytes.NewReader(section)
+
+	fields := []any{
+		&ph.GameId,
+		&ph.GameLength,
+		&ph.KeyframeCount,
+		&ph.ChunkCount,
+		&ph.EndStartupChunkId,
+		&ph.StartGameChunkId,
+		&ph.KeyframeInterval,
+		&ph.EncryptionKeyLength,
+	}
+	for _, field := range fields {
+		if err := binary.Read(buf, binary.LittleEndian, field); err != nil {
+			return ph, newParseError(SectionPayloadHeader, start+length-int64(buf.Len()),
+				fmt.Errorf("%w: error leyendo payload header: %v", ErrPayloadMalformed, err))
+		}
+	}
+
+	keyOffset := start + payloadHeaderFixedSize
+	if int(ph.EncryptionKeyLength) > buf.Len() {
+		return ph, newParseError(SectionPayloadHeader, keyOffset, fmt.Errorf("%w: clave de cifrado truncada: se esperaban %d bytes y quedan %d",
+			ErrPayloadMalformed, ph.EncryptionKeyLength, buf.Len()))
+	}
+	key := make([]byte, ph.EncryptionKeyLength)
+	if _, err := io.ReadFull(buf, key); err != nil {
+		return ph, newParseError(SectionPayloadHeader, keyOffset, fmt.Errorf("%w: error leyendo clave de cifrado: %v", ErrPayloadMalformed, err))
+	}
+	ph.EncryptionKey = string(key)
+
+	return ph, nil
+}
+
+// segmentHeaderSize es el tamaño de cada entrada del índice: id, tipo, longitud, siguiente id y offset
+const segmentHeaderSize = 17
+
+// segmentHeader es una entrada del índice de segmentos tal y como aparece en el archivo
+type segmentHeader struct {
+	Id     uint32
+	Type   byte
+	Length uint32
+	NextId uint32
+	Offset uint32
+}
+
+// parseSegments decodifica el índice de chunks y keyframes que empieza en Lengths.PayloadOffset.
+// Los offsets del índice son relativos al final del propio índice; se devuelven como posiciones absolutas.
+// Los datos de cada segmento solo se rellenan si el archivo está en memoria; si no, quedan para
+// cargarse bajo demanda con Rofl.ChunkData y Rofl.KeyframeData.
+func parseSegments(src *source, lengths model.Lengths, ph model.PayloadHeader) ([]model.Chunk, []model.Keyframe, error) {
+	count := int64(ph.ChunkCount) + int64(ph.KeyframeCount)
+	indexStart := int64(lengths.PayloadOffset)
+	if !src.inRange(indexStart, count*segmentHeaderSize) {
+		return nil, nil, newParseError(SectionSegments, indexStart, fmt.Errorf("%w: índice de segmentos fuera de rango: %d entradas, tamaño del archivo=%d",
+			ErrPayloadMalformed, count, src.size))
+	}
+	dataStart := indexStart + count*segmentHeaderSize
+
+	index, err := src.section(indexStart, count*segmentHeaderSize)
+	if err != nil {
+		return nil, nil, newParseError(SectionSegments, indexStart, err)
+	}
+	buf := bytes.NewReader(index)
+	chunks := make([]model.Chunk, 0, ph.ChunkCount)
+	keyframes := make([]model.Keyframe, 0, ph.KeyframeCount)
+	for i := int64(0); i < count; i++ {
+		entryOffset := indexStart + i*segmentHeaderSize
+		var sh segmentHeader
+		if err := binary.Read(buf, binary.LittleEndian, &sh); err != nil {
+			return nil, nil, newParseError(SectionSegments, entryOffset, fmt.Errorf("%w: error leyendo entrada %d del índice: %v", ErrPayloadMalformed, i, err))
+		}
+
+		start := dataStart + int64(sh.Offset)
+		if !src.inRange(start, int64(sh.Length)) {
+			return nil, nil, newParseError(SectionSegments, entryOffset, fmt.Errorf("%w: segmento %d (tipo %d) fuera de rango: offset=%d, longitud=%d, tamaño del archivo=%d",
+				ErrPayloadMalformed, sh.Id, sh.Type, start, sh.Length, src.size))
+		}
+		var data []byte
+		if src.inMemory() {
+			data, _ = src.section(start, int64(sh.Length))
+		}
+
+		switch sh.Type {
+		case model.SegmentTypeChunk:
+			chunks = append(chunks, model.Chunk{
+				Id:        sh.Id,
+				ChunkType: sh.Type,
+				Length:    sh.Length,
+				NextId:    sh.NextId,
+				Offset:    uint32(start),
+				Data:      data,
+			})
+		case model.SegmentTypeKeyframe:
+			keyframes = append(keyframes, model.Keyframe{
+				Id:           sh.Id,
+				KeyframeType: sh.Type,
+				Length:       sh.Length,
+				NextId:       sh.NextId,
+				Offset:       uint32(start),
+				Data:         data,
+			})
+		default:
+			return nil, nil, newParseError(SectionSegments, entryOffset, fmt.Errorf("%w: tipo de segmento desconocido %d en la entrada %d", ErrPayloadMalformed, sh.Type, i))
+		}
+	}
+
+	if len(chunks) != int(ph.ChunkCount) || len(keyframes) != int(ph.KeyframeCount) {
+		return nil, nil, newParseError(SectionSegments, indexStart, fmt.Errorf("%w: el índice no coincide con el payload header: %d chunks y %d keyframes, se esperaban %d y %d",
+			ErrPayloadMalformed, len(chunks), len(keyframes), ph.ChunkCount, ph.KeyframeCount))
+	}
+	return chunks, keyframes, nil
+}
@@ -0,0 +1,137 @@
+// Package payload descifra y descomprime los chunks y keyframes de un archivo .rofl.
+//
+// Cada segmento está cifrado con Blowfish en modo ECB y relleno PKCS#5, y su contenido
+// descifrado está comprimido con gzip. La clave de los segmentos se obtiene descifrando
+// PayloadHeader.EncryptionKey (en base64) con el GameId en decimal como clave.
+//
+// En el contenedor ROFL2 los segmentos no van cifrados y están comprimidos con zstd;
+// For elige el decodificador adecuado según Rofl.FormatVersion.
+package payload
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pointedsec/rofl-parser/model"
+	"golang.org/x/crypto/blowfish"
+)
+
+var (
+	// ErrInvalidKey indica que la clave de cifrado del payload header no es base64 válido o está vacía
+	ErrInvalidKey = errors.New("payload: clave de cifrado inválida")
+	// ErrWrongKey indica que la clave no corresponde a los datos: el resultado descifrado no tiene sentido
+	ErrWrongKey = errors.New("payload: clave incorrecta para los datos")
+	// ErrBadPadding indica que el relleno PKCS#5 del bloque descifrado no es válido
+	ErrBadPadding = errors.New("payload: relleno inválido")
+	// ErrBadLength indica que los datos cifrados no son múltiplo del tamaño de bloque
+	ErrBadLength = errors.New("payload: longitud de datos cifrados inválida")
+	// ErrNotLoaded indica que el segmento no tiene los datos cargados, como ocurre en los replays
+	// abiertos con NewFromReaderAt; Reader los lee del origen del replay
+	ErrNotLoaded = errors.New("payload: datos del segmento no cargados; usa payload.NewReader o Rofl.LoadData")
+)
+
+// Decoder descifra y descomprime segmentos usando la clave derivada del payload header
+type Decoder struct {
+	cipher *blowfish.Cipher
+}
+
+// NewDecoder deriva la clave de los segmentos a partir del GameId y la EncryptionKey del payload header
+func NewDecoder(header model.PayloadHeader) (*Decoder, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(header.EncryptionKey)
+	if err != nil || len(encrypted) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidKey, header.EncryptionKey)
+	}
+
+	gameCipher, err := blowfish.NewCipher([]byte(strconv.FormatUint(header.GameId, 10)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: GameId %d: %v", ErrInvalidKey, header.GameId, err)
+	}
+	key, err := decrypt(gameCipher, encrypted)
+	if err != nil {
+		// Un relleno incorrecto al derivar la clave significa que GameId y EncryptionKey no casan
+		if errors.Is(err, ErrBadPadding) {
+			return nil, fmt.Errorf("%w: la EncryptionKey no corresponde al GameId %d", ErrWrongKey, header.GameId)
+		}
+		return nil, err
+	}
+
+	segmentCipher, err := blowfish.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return &Decoder{cipher: segmentCipher}, nil
+}
+
+// Decode descifra y descomprime los datos crudos de un segmento
+func (d *Decoder) Decode(data []byte) ([]byte, error) {
+	compressed, err := decrypt(d.cipher, data)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		// Con una clave incorrecta el relleno puede cuadrar por casualidad, pero no la cabecera gzip
+		return nil, fmt.Errorf("%w: %v", ErrWrongKey, err)
+	}
+	defer zr.Close()
+
+	plain, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("payload: error descomprimiendo: %w", err)
+	}
+	return plain, nil
+}
+
+// DecodeChunk devuelve el contenido en claro de un chunk cuyos datos están cargados en c.Data.
+// Para replays abiertos con NewFromReaderAt usa Reader, que lee los datos del origen.
+func (d *Decoder) DecodeChunk(c model.Chunk) ([]byte, error) {
+	if c.Data == nil {
+		return nil, fmt.Errorf("chunk %d: %w", c.Id, ErrNotLoaded)
+	}
+	plain, err := d.Decode(c.Data)
+	if err != nil {
+		return nil, fmt.Errorf("chunk %d: %w", c.Id, err)
+	}
+	return plain, nil
+}
+
+// DecodeKeyframe devuelve el contenido en claro de un keyframe cuyos datos están cargados en k.Data.
+// Para replays abiertos con NewFromReaderAt usa Reader, que lee los datos del origen.
+func (d *Decoder) DecodeKeyframe(k model.Keyframe) ([]byte, error) {
+	if k.Data == nil {
+		return nil, fmt.Errorf("keyframe %d: %w", k.Id, ErrNotLoaded)
+	}
+	plain, err := d.Decode(k.Data)
+	if err != nil {
+		return nil, fmt.Errorf("keyframe %d: %w", k.Id, err)
+	}
+	return plain, nil
+}
+
+// decrypt descifra en modo ECB y elimina el relleno PKCS#5
+func decrypt(c *blowfish.Cipher, data []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%blowfish.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrBadLength, len(data))
+	}
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += blowfish.BlockSize {
+		c.Decrypt(out[i:i+blowfish.BlockSize], data[i:i+blowfish.BlockSize])
+	}
+
+	pad := int(out[len(out)-1])
+	if pad == 0 || pad > blowfish.BlockSize {
+		return nil, fmt.Errorf("%w: valor de relleno %d", ErrBadPadding, pad)
+	}
+	for _, b := range out[len(out)-pad:] {
+		if int(b) != pad {
+			return nil, fmt.Errorf("%w: bytes de relleno inconsistentes", ErrBadPadding)
+		}
+	}
+	return out[:len(out)-pad], nil
+}
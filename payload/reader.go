@@ -0,0 +1,51 @@
+package payload
+
+import (
+	"fmt"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// Reader decodifica los segmentos de un replay parseado. A diferencia de SegmentDecoder no
+// necesita que los datos estén cargados: los lee con Rofl.ChunkData y Rofl.KeyframeData, de modo
+// que funciona también con replays abiertos con NewFromReaderAt sin cargarlos enteros en memoria.
+type Reader struct {
+	rofl *model.Rofl
+	dec  SegmentDecoder
+}
+
+// NewReader crea un Reader con el decodificador adecuado para el formato de r
+func NewReader(r *model.Rofl) (*Reader, error) {
+	dec, err := For(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{rofl: r, dec: dec}, nil
+}
+
+// Chunk devuelve el contenido en claro de un chunk, leyendo sus datos del origen si no están cargados
+func (rd *Reader) Chunk(c model.Chunk) ([]byte, error) {
+	data, err := rd.rofl.ChunkData(c)
+	if err != nil {
+		return nil, fmt.Errorf("chunk %d: %w", c.Id, err)
+	}
+	c.Data = data
+	return rd.dec.DecodeChunk(c)
+}
+
+// Keyframe devuelve el contenido en claro de un keyframe, leyendo sus datos del origen si no están cargados
+func (rd *Reader) Keyframe(k model.Keyframe) ([]byte, error) {
+	data, err := rd.rofl.KeyframeData(k)
+	if err != nil {
+		return nil, fmt.Errorf("keyframe %d: %w", k.Id, err)
+	}
+	k.Data = data
+	return rd.dec.DecodeKeyframe(k)
+}
+
+// Close libera los recursos del decodificador, si los tiene
+func (rd *Reader) Close() {
+	if c, ok := rd.dec.(interface{ Close() }); ok {
+		c.Close()
+	}
+}
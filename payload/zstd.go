@@ -0,0 +1,79 @@
+package payload
+
+import (
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// SegmentDecoder devuelve el contenido en claro de los segmentos de un archivo .rofl
+type SegmentDecoder interface {
+	Decode(data []byte) ([]byte, error)
+	DecodeChunk(c model.Chunk) ([]byte, error)
+	DecodeKeyframe(k model.Keyframe) ([]byte, error)
+}
+
+// For devuelve el decodificador adecuado para el formato del archivo parseado
+func For(r *model.Rofl) (SegmentDecoder, error) {
+	switch r.FormatVersion {
+	case model.FormatV1:
+		return NewDecoder(r.PayloadHeader)
+	case model.FormatV2:
+		return NewZstdDecoder()
+	default:
+		return nil, fmt.Errorf("payload: formato no soportado: %s", r.FormatVersion)
+	}
+}
+
+// ZstdDecoder descomprime los segmentos de un ROFL2, que no van cifrados
+type ZstdDecoder struct {
+	dec *zstd.Decoder
+}
+
+// NewZstdDecoder crea un decodificador para segmentos ROFL2
+func NewZstdDecoder() (*ZstdDecoder, error) {
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, fmt.Errorf("payload: error creando decodificador zstd: %w", err)
+	}
+	return &ZstdDecoder{dec: dec}, nil
+}
+
+// Decode descomprime los datos crudos de un segmento
+func (d *ZstdDecoder) Decode(data []byte) ([]byte, error) {
+	plain, err := d.dec.DecodeAll(data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("payload: error descomprimiendo: %w", err)
+	}
+	return plain, nil
+}
+
+// DecodeChunk devuelve el contenido en claro de un chunk cuyos datos están cargados en c.Data
+func (d *ZstdDecoder) DecodeChunk(c model.Chunk) ([]byte, error) {
+	if c.Data == nil {
+		return nil, fmt.Errorf("chunk %d: %w", c.Id, ErrNotLoaded)
+	}
+	plain, err := d.Decode(c.Data)
+	if err != nil {
+		return nil, fmt.Errorf("chunk %d: %w", c.Id, err)
+	}
+	return plain, nil
+}
+
+// DecodeKeyframe devuelve el contenido en claro de un keyframe cuyos datos están cargados en k.Data
+func (d *ZstdDecoder) DecodeKeyframe(k model.Keyframe) ([]byte, error) {
+	if k.Data == nil {
+		return nil, fmt.Errorf("keyframe %d: %w", k.Id, ErrNotLoaded)
+	}
+	plain, err := d.Decode(k.Data)
+	if err != nil {
+		return nil, fmt.Errorf("keyframe %d: %w", k.Id, err)
+	}
+	return plain, nil
+}
+
+// Close libera los recursos del decodificador zstd
+func (d *ZstdDecoder) Close() {
+	d.dec.Close()
+}
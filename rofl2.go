@@ -0,0 +1,179 @@
+package roflparser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// zstdMagic es el magic number de un frame zstd en little endian
+const zstdMagic = 0xFD2FB528
+
+// zstdMaxBlockSize es el tamaño máximo de un bloque zstd
+const zstdMaxBlockSize = 1 << 17
+
+// detectFormat identifica la variante del contenedor a partir del magic number
+func detectFormat(magic [6]byte) (model.FormatVersion, error) {
+	if !bytes.HasPrefix(magic[:], []byte("RIOT")) {
+		return model.FormatUnknown, newParseError(SectionMagic, 0, fmt.Errorf("%w: %v", ErrInvalidMagic, magic))
+	}
+	switch magic[4] {
+	case 0x00:
+		return model.FormatV1, nil
+	case 0x02:
+		return model.FormatV2, nil
+	default:
+		return model.FormatUnknown, newParseError(SectionMagic, 4, fmt.Errorf("%w: %d", ErrUnsupportedFormat, magic[4]))
+	}
+}
+
+// metadataFromFooter lee la metadata de un ROFL2: los últimos 4 bytes guardan la longitud
+// del bloque JSON, que está justo antes. Devuelve también el offset donde empieza el bloque.
+func metadataFromFooter(src *source) ([]byte, int64, bool) {
+	if src.size < magicSize+4 {
+		return nil, 0, false
+	}
+	footer := src.size - 4
+	lengthBytes, err := src.section(footer, 4)
+	if err != nil {
+		return nil, 0, false
+	}
+	length := int64(binary.LittleEndian.Uint32(lengthBytes))
+	if length == 0 || length > footer-magicSize {
+		return nil, 0, false
+	}
+	start := footer - length
+	metaBytes, err := src.section(start, length)
+	if err != nil || metaBytes[0] != '{' || !json.Valid(metaBytes) {
+		return nil, 0, false
+	}
+	return metaBytes, start, true
+}
+
+// extractMetadataV2 localiza la metadata de un ROFL2 por el pie del archivo y, si es
+// inconsistente, por contenido. Devuelve el offset donde empieza el bloque, que es también
+// donde termina la zona de segmentos.
+func extractMetadataV2(src *source) ([]byte, model.MetadataStrategy, int64, error) {
+	if metaBytes, start, ok := metadataFromFooter(src); ok {
+		return metaBytes, model.MetadataFromFooter, start, nil
+	}
+	metaBytes, start, err := metadataFromScan(src)
+	if err != nil {
+		return nil, model.MetadataFromScan, 0, err
+	}
+	return metaBytes, model.MetadataFromScan, start, nil
+}
+
+// parseSegmentsV2 recorre la zona de segmentos de un ROFL2 y devuelve cada frame zstd como un chunk
+// sin tipo (ChunkType 0), numerado por orden de aparición.
+// El contenedor no tiene índice, así que los límites de cada segmento se obtienen de las propias
+// cabeceras de frame y bloque zstd; los bytes que no forman un frame válido se ignoran.
+// Como en parseSegments, los datos solo se rellenan si el archivo está en memoria.
+func parseSegmentsV2(src *source, end int64) ([]model.Chunk, error) {
+	var chunks []model.Chunk
+	magic := binary.LittleEndian.AppendUint32(nil, zstdMagic)
+	pos := int64(magicSize)
+	for pos < end {
+		start, err := src.index(magic, pos, end)
+		if err != nil {
+			return nil, newParseError(SectionSegments, pos, err)
+		}
+		if start == -1 {
+			break
+		}
+		size, ok := zstdFrameSize(src, start, end)
+		if !ok {
+			pos = start + 1
+			continue
+		}
+		var data []byte
+		if src.inMemory() {
+			data, _ = src.section(start, size)
+		}
+		chunks = append(chunks, model.Chunk{
+			Id:     uint32(len(chunks) + 1),
+			Length: uint32(size),
+			Offset: uint32(start),
+			Data:   data,
+		})
+		pos = start + size
+	}
+	return chunks, nil
+}
+
+// zstdFrameSize calcula el tamaño del frame zstd que empieza en start leyendo solo su cabecera
+// y las cabeceras de sus bloques, sin descomprimirlo ni salir de [start, end)
+func zstdFrameSize(src *source, start, end int64) (int64, bool) {
+	read := func(off, n int64) []byte {
+		if off+n > end {
+			return nil
+		}
+		b, err := src.section(off, n)
+		if err != nil {
+			return nil
+		}
+		return b
+	}
+
+	header := read(start, 5)
+	if header == nil || binary.LittleEndian.Uint32(header) != zstdMagic {
+		return 0, false
+	}
+	descriptor := header[4]
+	if descriptor&0x08 != 0 {
+		// Bit reservado
+		return 0, false
+	}
+	singleSegment := descriptor&0x20 != 0
+	hasChecksum := descriptor&0x04 != 0
+
+	pos := start + 5
+	if !singleSegment {
+		pos++ // window descriptor
+	}
+	pos += [4]int64{0, 1, 2, 4}[descriptor&0x03]
+	contentSizeBytes := [4]int64{0, 2, 4, 8}[descriptor>>6]
+	if contentSizeBytes == 0 && singleSegment {
+		contentSizeBytes = 1
+	}
+	pos += contentSizeBytes
+
+	for {
+		b := read(pos, 3)
+		if b == nil {
+			return 0, false
+		}
+		blockHeader := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+		pos += 3
+		last := blockHeader&1 != 0
+		blockSize := int64(blockHeader >> 3)
+		switch (blockHeader >> 1) & 0x03 {
+		case 0, 2: // raw, comprimido
+			if blockSize > zstdMaxBlockSize {
+				return 0, false
+			}
+			pos += blockSize
+		case 1: // RLE
+			pos++
+		default:
+			return 0, false
+		}
+		if pos > end {
+			return 0, false
+		}
+		if last {
+			break
+		}
+	}
+
+	if hasChecksum {
+		pos += 4
+	}
+	if pos > end {
+		return 0, false
+	}
+	return pos - start, true
+}
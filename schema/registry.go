@@ -0,0 +1,100 @@
+package schema
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// builtin son los esquemas incluidos en el paquete, uno por archivo
+//
+//go:embed schemas/*.json
+var builtin embed.FS
+
+var defaultRegistry = mustLoadBuiltin()
+
+// mustLoadBuiltin carga los esquemas incluidos; un error aquí solo puede deberse a un archivo mal escrito en schemas/
+func mustLoadBuiltin() *Registry {
+	sub, _ := fs.Sub(builtin, "schemas")
+	schemas, err := Load(sub)
+	if err != nil {
+		panic(err)
+	}
+	return NewRegistry(schemas...)
+}
+
+// Registry guarda los esquemas de cada intervalo de parches. Es seguro para uso concurrente.
+type Registry struct {
+	mu      sync.RWMutex
+	schemas []Schema
+}
+
+// NewRegistry crea un registro con los esquemas dados
+func NewRegistry(schemas ...Schema) *Registry {
+	return &Registry{schemas: append([]Schema(nil), schemas...)}
+}
+
+// Default devuelve el registro que usa el parser si no se indica otro, con los esquemas incluidos
+// en el paquete. Los esquemas añadidos con Register afectan a todos los parseos posteriores.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register añade un esquema. Si varios esquemas contienen la misma versión se usa el último
+// registrado, de modo que se pueden sustituir los incluidos en el paquete.
+func (r *Registry) Register(s Schema) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas = append(r.schemas, s)
+}
+
+// Lookup devuelve el esquema cuyo intervalo contiene el parche de v
+func (r *Registry) Lookup(v model.GameVersion) (*Schema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.schemas) - 1; i >= 0; i-- {
+		if r.schemas[i].Versions.Contains(v) {
+			s := r.schemas[i]
+			return &s, true
+		}
+	}
+	return nil, false
+}
+
+// Schemas devuelve los esquemas registrados, en orden de registro
+func (r *Registry) Schemas() []Schema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Schema(nil), r.schemas...)
+}
+
+// Load lee los esquemas de los archivos .json de la raíz de fsys, cada uno con un Schema
+func Load(fsys fs.FS) ([]Schema, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]Schema, 0, len(names))
+	for _, name := range names {
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo el esquema %s: %w", name, err)
+		}
+		var s Schema
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("error decodificando el esquema %s: %w", name, err)
+		}
+		schemas = append(schemas, s)
+	}
+	return schemas, nil
+}
+
+// LoadDir lee los esquemas de los archivos .json del directorio dir
+func LoadDir(dir string) ([]Schema, error) {
+	return Load(os.DirFS(dir))
+}
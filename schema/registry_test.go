@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+func TestLookupUnversioned(t *testing.T) {
+	patch, _ := model.ParseVersionRange("13.3")
+	r := NewRegistry(Schema{Versions: patch}, Schema{Versions: model.UnversionedRange()})
+
+	s, ok := r.Lookup(model.GameVersion{})
+	if !ok || !s.Versions.Unversioned {
+		t.Fatalf("los replays sin versión deben usar el esquema unversioned: %+v", s)
+	}
+	v, _ := model.ParseGameVersion("13.3.491.6222")
+	if s, ok := r.Lookup(v); !ok || s.Versions != patch {
+		t.Fatalf("el esquema unversioned no debe aplicarse a replays con versión: %+v", s)
+	}
+	v, _ = model.ParseGameVersion("14.1.1.1")
+	if s, ok := r.Lookup(v); ok {
+		t.Fatalf("no debería haber esquema para 14.1: %+v", s)
+	}
+}
+
+func TestDefaultCoversUnversionedReplays(t *testing.T) {
+	if _, ok := Default().Lookup(model.GameVersion{}); !ok {
+		t.Fatal("el registro por defecto debe incluir un esquema para los replays sin versión")
+	}
+}
@@ -0,0 +1,155 @@
+// Package schema guarda qué claves de la metadata y de statsJson se esperan en cada parche, para
+// que la validación del parser solo informe de las diferencias reales y no de las claves que un
+// parche antiguo o nuevo no tiene.
+//
+// Cada Schema cubre un intervalo de parches. El paquete incluye los esquemas generados a partir de
+// replays de ejemplo (Default) y Generate crea uno nuevo a partir de replays de otro parche; la
+// herramienta rofl schema hace lo mismo desde la línea de comandos.
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// ErrNoSamples indica que no hay ningún replay con estadísticas del que generar un esquema
+var ErrNoSamples = errors.New("no hay replays con estadísticas para generar el esquema")
+
+// Schema describe las claves de la metadata y de las estadísticas de los jugadores de un intervalo de parches
+type Schema struct {
+	Versions model.VersionRange `json:"versions"`
+	// Metadata son las claves obligatorias del bloque de metadata
+	Metadata []string `json:"metadata"`
+	// OptionalMetadata son claves de metadata que se aceptan pero no aparecen en todos los replays
+	OptionalMetadata []string `json:"optionalMetadata,omitempty"`
+	// Stats son las claves que tienen todos los jugadores
+	Stats []string `json:"stats"`
+	// OptionalStats son claves que se aceptan pero no son obligatorias, porque solo aparecen en
+	// algunos jugadores o modos de juego (por ejemplo, las de Arena)
+	OptionalStats []string `json:"optionalStats,omitempty"`
+}
+
+// ValidateMetadata compara las claves de la metadata con el esquema
+func (s *Schema) ValidateMetadata(meta map[string]interface{}) model.MetadataValidationError {
+	missing, extra := diff(meta, s.Metadata, s.OptionalMetadata)
+	return model.MetadataValidationError{
+		Schema:        s.Versions.String(),
+		MissingFields: missing,
+		ExtraFields:   extra,
+	}
+}
+
+// ValidateStats compara las claves de las estadísticas de un jugador con el esquema
+func (s *Schema) ValidateStats(playerIndex int, stats map[string]interface{}) model.PlayerStatsValidationError {
+	missing, extra := diff(stats, s.Stats, s.OptionalStats)
+	return model.PlayerStatsValidationError{
+		PlayerIndex:   playerIndex,
+		MissingFields: missing,
+		ExtraFields:   extra,
+	}
+}
+
+// diff devuelve, ordenadas, las claves obligatorias que faltan en m y las claves de m que no son
+// obligatorias ni opcionales
+func diff(m map[string]interface{}, required, optional []string) (missing, extra []string) {
+	missing, extra = []string{}, []string{}
+	known := make(map[string]bool, len(required)+len(optional))
+	for _, key := range required {
+		known[key] = true
+		if _, ok := m[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	for _, key := range optional {
+		known[key] = true
+	}
+	for key := range m {
+		if !known[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	return missing, extra
+}
+
+// Generate crea el esquema del intervalo versions a partir de uno o varios replays de ejemplo: las
+// claves de metadata presentes en todos los replays y las de estadísticas presentes en todos los
+// jugadores de todos los replays son obligatorias, y las que solo aparecen en algunos, opcionales.
+// Conviene usar replays de varios modos de juego para que sus claves propias queden como opcionales.
+// Las claves de metadata se leen de Rofl.RawMetadata; en los replays que no lo tienen se usan las
+// de model.MetadataJson.
+func Generate(versions model.VersionRange, replays ...*model.Rofl) (Schema, error) {
+	metadataCount := map[string]int{}
+	statsCount := map[string]int{}
+	samples, players := 0, 0
+	for _, r := range replays {
+		if r.Metadata.StatsJSON == "" {
+			continue
+		}
+		keys, err := sampleMetadataKeys(r)
+		if err != nil {
+			return Schema{}, err
+		}
+		for _, key := range keys {
+			metadataCount[key]++
+		}
+		var stats []map[string]interface{}
+		if err := json.Unmarshal([]byte(r.Metadata.StatsJSON), &stats); err != nil {
+			return Schema{}, fmt.Errorf("error decodificando statsJson: %w", err)
+		}
+		for _, p := range stats {
+			for key := range p {
+				statsCount[key]++
+			}
+		}
+		samples++
+		players += len(stats)
+	}
+	if samples == 0 {
+		return Schema{}, ErrNoSamples
+	}
+
+	s := Schema{Versions: versions}
+	s.Metadata, s.OptionalMetadata = splitKeys(metadataCount, samples)
+	s.Stats, s.OptionalStats = splitKeys(statsCount, players)
+	return s, nil
+}
+
+// splitKeys separa, ordenadas, las claves que aparecen en las total muestras de las que solo
+// aparecen en algunas
+func splitKeys(counts map[string]int, total int) (required, optional []string) {
+	required = []string{}
+	for key, n := range counts {
+		if n == total {
+			required = append(required, key)
+		} else {
+			optional = append(optional, key)
+		}
+	}
+	sort.Strings(required)
+	sort.Strings(optional)
+	return required, optional
+}
+
+// sampleMetadataKeys devuelve las claves del bloque de metadata original de r o, si no se conserva,
+// las de model.MetadataJson
+func sampleMetadataKeys(r *model.Rofl) ([]string, error) {
+	raw := r.RawMetadata
+	if len(raw) == 0 {
+		raw, _ = json.Marshal(model.MetadataJson{})
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("error decodificando la metadata: %w", err)
+	}
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,35 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+func TestGenerateMetadataFromSamples(t *testing.T) {
+	sample := func(metadata string) *model.Rofl {
+		return &model.Rofl{
+			Metadata:    model.MetadataJson{StatsJSON: `[{"NAME":"a"}]`},
+			RawMetadata: []byte(metadata),
+		}
+	}
+	s, err := Generate(model.VersionRange{},
+		sample(`{"gameLength":1,"statsJson":"","newKey":1,"onlyHere":true}`),
+		sample(`{"gameLength":1,"statsJson":"","newKey":2}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"gameLength", "newKey", "statsJson"}; !reflect.DeepEqual(s.Metadata, want) {
+		t.Fatalf("Metadata = %v, se esperaba %v", s.Metadata, want)
+	}
+	if want := []string{"onlyHere"}; !reflect.DeepEqual(s.OptionalMetadata, want) {
+		t.Fatalf("OptionalMetadata = %v, se esperaba %v", s.OptionalMetadata, want)
+	}
+
+	v := s.ValidateMetadata(map[string]interface{}{"gameLength": 1, "statsJson": "", "newKey": 3, "onlyHere": false})
+	if len(v.MissingFields) != 0 || len(v.ExtraFields) != 0 {
+		t.Fatalf("validación inesperada: %+v", v)
+	}
+}
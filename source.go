@@ -0,0 +1,82 @@
+package roflparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// scanWindow es el tamaño de las lecturas usadas al buscar secuencias dentro del archivo
+const scanWindow = 1 << 20
+
+// source da acceso aleatorio al archivo .rofl. Si el archivo completo está en memoria,
+// las secciones se devuelven sin copiar; si no, se leen bajo demanda desde el io.ReaderAt.
+type source struct {
+	r    io.ReaderAt
+	size int64
+	buf  []byte
+}
+
+// newBytesSource crea un source sobre un archivo ya cargado en memoria
+func newBytesSource(allBytes []byte) *source {
+	return &source{r: bytes.NewReader(allBytes), size: int64(len(allBytes)), buf: allBytes}
+}
+
+// newReaderAtSource crea un source que lee bajo demanda desde r
+func newReaderAtSource(r io.ReaderAt, size int64) *source {
+	return &source{r: r, size: size}
+}
+
+// inMemory indica si el archivo completo está cargado en memoria
+func (s *source) inMemory() bool {
+	return s.buf != nil
+}
+
+// inRange comprueba que la sección [off, off+n) esté dentro del archivo
+func (s *source) inRange(off, n int64) bool {
+	return off >= 0 && n >= 0 && off <= s.size && n <= s.size-off
+}
+
+// section devuelve n bytes a partir de off
+func (s *source) section(off, n int64) ([]byte, error) {
+	if !s.inRange(off, n) {
+		return nil, fmt.Errorf("sección fuera de rango: offset=%d, longitud=%d, tamaño del archivo=%d", off, n, s.size)
+	}
+	if s.buf != nil {
+		return s.buf[off : off+n], nil
+	}
+	b := make([]byte, n)
+	read, err := s.r.ReadAt(b, off)
+	if read == len(b) {
+		return b, nil
+	}
+	if err == nil || errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return nil, fmt.Errorf("error leyendo %d bytes en offset %d: %w", n, off, err)
+}
+
+// index busca la primera aparición de pattern en [from, to) leyendo por ventanas,
+// de modo que no hace falta tener el archivo completo en memoria. Devuelve -1 si no aparece.
+func (s *source) index(pattern []byte, from, to int64) (int64, error) {
+	if to > s.size {
+		to = s.size
+	}
+	overlap := int64(len(pattern) - 1)
+	for pos := from; pos < to; {
+		n := min(int64(scanWindow), to-pos)
+		window, err := s.section(pos, n)
+		if err != nil {
+			return -1, err
+		}
+		if idx := bytes.Index(window, pattern); idx != -1 {
+			return pos + int64(idx), nil
+		}
+		if pos+n >= to {
+			break
+		}
+		pos += max(n-overlap, 1)
+	}
+	return -1, nil
+}
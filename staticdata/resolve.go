@@ -0,0 +1,61 @@
+package staticdata
+
+import "github.com/pointedsec/rofl-parser/model"
+
+// Player son los nombres e iconos de los identificadores de las estadísticas de un jugador.
+// Cada campo es nil si el id es 0 o no existe en la versión de Data Dragon usada.
+type Player struct {
+	Champion       *Champion         `json:"champion,omitempty"`
+	Items          [7]*Item          `json:"items"`
+	Keystone       *Rune             `json:"keystone,omitempty"`
+	Perks          [6]*Rune          `json:"perks"`
+	PrimaryStyle   *RuneStyle        `json:"primaryStyle,omitempty"`
+	SubStyle       *RuneStyle        `json:"subStyle,omitempty"`
+	StatPerks      [3]*Rune          `json:"statPerks"`
+	SummonerSpells [2]*SummonerSpell `json:"summonerSpells"`
+}
+
+// Resolve resuelve SKIN, ITEM0-ITEM6, KEYSTONE_ID, PERK0-PERK5, PERK_PRIMARY_STYLE, PERK_SUB_STYLE,
+// STAT_PERK_0-2 y SUMMONER_SPELL_1-2 de p
+func (s *Snapshot) Resolve(p model.PlayerStats) Player {
+	var res Player
+	res.Champion = lookup(s.Champion, p.Skin)
+	for i, id := range []int{p.Item0, p.Item1, p.Item2, p.Item3, p.Item4, p.Item5, p.Item6} {
+		res.Items[i] = lookup(s.Item, id)
+	}
+	res.Keystone = lookup(s.Rune, p.KeystoneID)
+	for i, id := range []int{p.Perk0, p.Perk1, p.Perk2, p.Perk3, p.Perk4, p.Perk5} {
+		res.Perks[i] = lookup(s.Rune, id)
+	}
+	res.PrimaryStyle = lookup(s.RuneStyle, p.PerkPrimaryStyle)
+	res.SubStyle = lookup(s.RuneStyle, p.PerkSubStyle)
+	for i, id := range []int{p.StatPerk0, p.StatPerk1, p.StatPerk2} {
+		res.StatPerks[i] = lookup(s.Rune, id)
+	}
+	for i, id := range []int{p.SummonerSpell1, p.SummonerSpell2} {
+		res.SummonerSpells[i] = lookup(s.SummonerSpell, id)
+	}
+	return res
+}
+
+// Resolve elige la versión de Data Dragon de meta.GameVersion y resuelve los identificadores de cada jugador
+func (l *Library) Resolve(meta model.MetadataJson, players []model.PlayerStats) ([]Player, error) {
+	s, err := l.Snapshot(meta.GameVersion)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make([]Player, len(players))
+	for i, p := range players {
+		resolved[i] = s.Resolve(p)
+	}
+	return resolved, nil
+}
+
+// lookup devuelve un puntero al resultado de find, o nil si no existe
+func lookup[K comparable, V any](find func(K) (V, bool), key K) *V {
+	v, ok := find(key)
+	if !ok {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,226 @@
+package staticdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Champion es un campeón de champion.json
+type Champion struct {
+	ID    int    `json:"id"`
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	Icon  string `json:"icon"`
+}
+
+// Item es un objeto de item.json
+type Item struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+}
+
+// Rune es una runa de runesReforged.json o un fragmento de estadística
+type Rune struct {
+	ID   int    `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+}
+
+// RuneStyle es una rama de runas (Precisión, Dominación...)
+type RuneStyle struct {
+	ID   int    `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+}
+
+// SummonerSpell es un hechizo de invocador de summoner.json
+type SummonerSpell struct {
+	ID   int    `json:"id"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+}
+
+// statPerks son los fragmentos de estadística de STAT_PERK_*, que no aparecen en runesReforged.json
+var statPerks = map[int]Rune{
+	5001: {5001, "HealthScaling", "Health Scaling", "img/perk-images/StatMods/StatModsHealthPlusIcon.png"},
+	5002: {5002, "Armor", "Armor", "img/perk-images/StatMods/StatModsArmorIcon.png"},
+	5003: {5003, "MagicRes", "Magic Resist", "img/perk-images/StatMods/StatModsMagicResIcon.MagicResist_Fix.png"},
+	5005: {5005, "AttackSpeed", "Attack Speed", "img/perk-images/StatMods/StatModsAttackSpeedIcon.png"},
+	5007: {5007, "CDRScaling", "Ability Haste", "img/perk-images/StatMods/StatModsCDRScalingIcon.png"},
+	5008: {5008, "Adaptive", "Adaptive Force", "img/perk-images/StatMods/StatModsAdaptiveForceIcon.png"},
+	5010: {5010, "MovementSpeed", "Move Speed", "img/perk-images/StatMods/StatModsMovementSpeedIcon.png"},
+	5011: {5011, "HealthPlus", "Health", "img/perk-images/StatMods/StatModsHealthFlatIcon.png"},
+	5013: {5013, "Tenacity", "Tenacity and Slow Resist", "img/perk-images/StatMods/StatModsTenacityIcon.png"},
+}
+
+// Snapshot son los datos de una versión de Data Dragon. Las rutas de los iconos son relativas
+// a la raíz de Library.FS.
+type Snapshot struct {
+	Version        string
+	Champions      map[string]Champion
+	Items          map[int]Item
+	Runes          map[int]Rune
+	RuneStyles     map[int]RuneStyle
+	SummonerSpells map[int]SummonerSpell
+
+	// championsByName indexa Champions por nombre interno en minúsculas, porque SKIN no siempre
+	// coincide en mayúsculas con Data Dragon (FiddleSticks y Fiddlesticks)
+	championsByName map[string]Champion
+}
+
+// ddImage es el campo image de los JSON de Data Dragon
+type ddImage struct {
+	Full string `json:"full"`
+}
+
+// loadSnapshot lee los JSON de una versión de Data Dragon
+func loadSnapshot(fsys fs.FS, version, locale string) (*Snapshot, error) {
+	s := &Snapshot{
+		Version:         version,
+		Champions:       map[string]Champion{},
+		Items:           map[int]Item{},
+		Runes:           map[int]Rune{},
+		RuneStyles:      map[int]RuneStyle{},
+		SummonerSpells:  map[int]SummonerSpell{},
+		championsByName: map[string]Champion{},
+	}
+	dataDir := path.Join(version, "data", locale)
+	imgDir := path.Join(version, "img")
+
+	var champions struct {
+		Data map[string]struct {
+			ID    string  `json:"id"`
+			Key   string  `json:"key"`
+			Name  string  `json:"name"`
+			Title string  `json:"title"`
+			Image ddImage `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(fsys, path.Join(dataDir, "champion.json"), &champions); err != nil {
+		return nil, err
+	}
+	for _, c := range champions.Data {
+		id, _ := strconv.Atoi(c.Key)
+		champion := Champion{ID: id, Key: c.ID, Name: c.Name, Title: c.Title, Icon: path.Join(imgDir, "champion", c.Image.Full)}
+		s.Champions[c.ID] = champion
+		s.championsByName[strings.ToLower(c.ID)] = champion
+	}
+
+	var items struct {
+		Data map[string]struct {
+			Name  string  `json:"name"`
+			Image ddImage `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(fsys, path.Join(dataDir, "item.json"), &items); err != nil {
+		return nil, err
+	}
+	for key, it := range items.Data {
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		s.Items[id] = Item{ID: id, Name: it.Name, Icon: path.Join(imgDir, "item", it.Image.Full)}
+	}
+
+	var summoners struct {
+		Data map[string]struct {
+			ID    string  `json:"id"`
+			Key   string  `json:"key"`
+			Name  string  `json:"name"`
+			Image ddImage `json:"image"`
+		} `json:"data"`
+	}
+	if err := readJSON(fsys, path.Join(dataDir, "summoner.json"), &summoners); err != nil {
+		return nil, err
+	}
+	for _, sp := range summoners.Data {
+		id, err := strconv.Atoi(sp.Key)
+		if err != nil {
+			continue
+		}
+		s.SummonerSpells[id] = SummonerSpell{ID: id, Key: sp.ID, Name: sp.Name, Icon: path.Join(imgDir, "spell", sp.Image.Full)}
+	}
+
+	var styles []struct {
+		ID    int    `json:"id"`
+		Key   string `json:"key"`
+		Name  string `json:"name"`
+		Icon  string `json:"icon"`
+		Slots []struct {
+			Runes []struct {
+				ID   int    `json:"id"`
+				Key  string `json:"key"`
+				Name string `json:"name"`
+				Icon string `json:"icon"`
+			} `json:"runes"`
+		} `json:"slots"`
+	}
+	if err := readJSON(fsys, path.Join(dataDir, "runesReforged.json"), &styles); err != nil {
+		return nil, err
+	}
+	// Los iconos de las runas están en img/ en la raíz de Data Dragon, no en el directorio de la versión
+	for _, st := range styles {
+		s.RuneStyles[st.ID] = RuneStyle{ID: st.ID, Key: st.Key, Name: st.Name, Icon: path.Join("img", st.Icon)}
+		for _, slot := range st.Slots {
+			for _, r := range slot.Runes {
+				s.Runes[r.ID] = Rune{ID: r.ID, Key: r.Key, Name: r.Name, Icon: path.Join("img", r.Icon)}
+			}
+		}
+	}
+	return s, nil
+}
+
+// readJSON decodifica el archivo name de fsys en v
+func readJSON(fsys fs.FS, name string, v any) error {
+	b, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("error leyendo %s: %w", name, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("error decodificando %s: %w", name, err)
+	}
+	return nil
+}
+
+// Champion busca un campeón por su nombre interno, tal y como aparece en SKIN
+func (s *Snapshot) Champion(name string) (Champion, bool) {
+	c, ok := s.championsByName[strings.ToLower(name)]
+	return c, ok
+}
+
+// Item busca un objeto por su id
+func (s *Snapshot) Item(id int) (Item, bool) {
+	it, ok := s.Items[id]
+	return it, ok
+}
+
+// Rune busca una runa o un fragmento de estadística por su id
+func (s *Snapshot) Rune(id int) (Rune, bool) {
+	if r, ok := s.Runes[id]; ok {
+		return r, true
+	}
+	r, ok := statPerks[id]
+	return r, ok
+}
+
+// RuneStyle busca una rama de runas por su id
+func (s *Snapshot) RuneStyle(id int) (RuneStyle, bool) {
+	st, ok := s.RuneStyles[id]
+	return st, ok
+}
+
+// SummonerSpell busca un hechizo de invocador por su id
+func (s *Snapshot) SummonerSpell(id int) (SummonerSpell, bool) {
+	sp, ok := s.SummonerSpells[id]
+	return sp, ok
+}
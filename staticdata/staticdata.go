@@ -0,0 +1,141 @@
+// Package staticdata resuelve los identificadores numéricos de las estadísticas (objetos, runas,
+// hechizos de invocador) y los nombres de campeón de SKIN a nombres e iconos legibles, usando una
+// copia local de Data Dragon.
+//
+// Data Dragon se puede leer desde un directorio (OpenDir), desde un archivo embebido con embed.FS o
+// desde cualquier otro fs.FS (por ejemplo, un zip abierto con archive/zip). Se espera la estructura
+// de dragontail: un directorio por versión con data/<locale>/*.json e img/, y los iconos de las runas
+// en img/ en la raíz.
+package staticdata
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"sync"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// ErrNoSnapshot indica que el directorio de Data Dragon no contiene ninguna versión con datos para el idioma pedido
+var ErrNoSnapshot = errors.New("no se encontró ninguna versión de Data Dragon")
+
+// Option configura una Library
+type Option func(*Library)
+
+// WithLocale elige el idioma de los nombres. Por defecto se usa en_US.
+func WithLocale(locale string) Option {
+	return func(l *Library) {
+		l.locale = locale
+	}
+}
+
+// Library da acceso a las versiones de Data Dragon disponibles en un fs.FS. Cada versión se carga
+// la primera vez que se pide y se guarda para las siguientes. Es segura para uso concurrente.
+type Library struct {
+	fsys     fs.FS
+	locale   string
+	versions []ddVersion
+
+	mu        sync.Mutex
+	snapshots map[string]*Snapshot
+}
+
+// ddVersion es un directorio de versión de Data Dragon, como 15.4.1
+type ddVersion struct {
+	name    string
+	version model.GameVersion
+}
+
+// Open busca las versiones de Data Dragon disponibles en fsys
+func Open(fsys fs.FS, opts ...Option) (*Library, error) {
+	l := &Library{fsys: fsys, locale: "en_US", snapshots: map[string]*Snapshot{}}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo Data Dragon: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		version, err := model.ParseGameVersion(entry.Name())
+		if err != nil {
+			continue
+		}
+		if _, err := fs.Stat(fsys, path.Join(entry.Name(), "data", l.locale, "champion.json")); err != nil {
+			continue
+		}
+		l.versions = append(l.versions, ddVersion{name: entry.Name(), version: version})
+	}
+	if len(l.versions) == 0 {
+		return nil, fmt.Errorf("%w para %s", ErrNoSnapshot, l.locale)
+	}
+	sort.Slice(l.versions, func(i, j int) bool {
+		return l.versions[i].version.Before(l.versions[j].version)
+	})
+	return l, nil
+}
+
+// OpenDir busca las versiones de Data Dragon del directorio dir
+func OpenDir(dir string, opts ...Option) (*Library, error) {
+	return Open(os.DirFS(dir), opts...)
+}
+
+// FS devuelve el sistema de archivos de Data Dragon, del que se pueden leer los iconos
+func (l *Library) FS() fs.FS {
+	return l.fsys
+}
+
+// Versions devuelve las versiones disponibles, de la más antigua a la más reciente
+func (l *Library) Versions() []string {
+	names := make([]string, len(l.versions))
+	for i, v := range l.versions {
+		names[i] = v.name
+	}
+	return names
+}
+
+// Snapshot devuelve la versión de Data Dragon que corresponde a gameVersion (por ejemplo,
+// "15.4.655.8470" usa 15.4.x). Si no hay una del mismo parche se usa la más reciente anterior a
+// él y, si tampoco la hay, la más antigua. Con una gameVersion vacía o ilegible se usa la más reciente.
+func (l *Library) Snapshot(gameVersion string) (*Snapshot, error) {
+	return l.load(l.pick(gameVersion))
+}
+
+// pick elige la versión de Data Dragon para gameVersion
+func (l *Library) pick(gameVersion string) ddVersion {
+	latest := l.versions[len(l.versions)-1]
+	game, err := model.ParseGameVersion(gameVersion)
+	if err != nil {
+		return latest
+	}
+	// Las versiones de Data Dragon de un parche son 15.4.1, 15.4.2...; de la partida solo cuenta el parche
+	for i := len(l.versions) - 1; i >= 0; i-- {
+		if l.versions[i].version.ComparePatch(game) <= 0 {
+			return l.versions[i]
+		}
+	}
+	return l.versions[0]
+}
+
+// load carga una versión o la devuelve de la caché
+func (l *Library) load(v ddVersion) (*Snapshot, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if s, ok := l.snapshots[v.name]; ok {
+		return s, nil
+	}
+	s, err := loadSnapshot(l.fsys, v.name, l.locale)
+	if err != nil {
+		return nil, err
+	}
+	l.snapshots[v.name] = s
+	return s, nil
+}
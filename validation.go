@@ -0,0 +1,185 @@
+package roflparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// maxTeamSize es el máximo de jugadores por lado en las colas con equipo azul y rojo
+const maxTeamSize = 5
+
+// validateStrict revisa el replay ya parseado y devuelve un *model.ValidationError con todos los
+// problemas encontrados, o nil si no hay ninguno. Los campos faltantes o extra solo cuentan si
+// schemaMatched indica que había un esquema registrado para la versión del replay: las claves de
+// structSchema no describen ningún parche concreto.
+func validateStrict(r *model.Rofl, schemaMatched bool, metaErr *model.MetadataValidationError, statsErrs []model.PlayerStatsValidationError) error {
+	var problems []model.ValidationProblem
+	schema := ""
+	if metaErr != nil {
+		schema = metaErr.Schema
+	}
+	if schemaMatched {
+		if metaErr != nil {
+			problems = append(problems, fieldProblems(-1, metaErr.MissingFields, metaErr.ExtraFields)...)
+		}
+		for _, statsErr := range statsErrs {
+			problems = append(problems, fieldProblems(statsErr.PlayerIndex, statsErr.MissingFields, statsErr.ExtraFields)...)
+		}
+	}
+
+	var raw []json.RawMessage
+	if r.Metadata.StatsJSON != "" {
+		if err := json.Unmarshal([]byte(r.Metadata.StatsJSON), &raw); err != nil {
+			// Sin jugadores que revisar, el resto de comprobaciones solo añadirían ruido
+			problems = append(problems, model.ValidationProblem{
+				Kind:        model.ProblemInvalidValue,
+				PlayerIndex: -1,
+				Key:         "statsJson",
+				Detail:      err.Error(),
+			})
+			return validationError(schema, problems)
+		}
+	}
+	players := make([]model.PlayerStats, len(raw))
+	for idx, rawStats := range raw {
+		player, err := model.DecodePlayerStats(rawStats)
+		if convErr, ok := err.(*model.StatsConversionError); ok {
+			for _, f := range convErr.Fields {
+				problems = append(problems, model.ValidationProblem{
+					Kind:        model.ProblemInvalidValue,
+					PlayerIndex: idx,
+					Key:         f.Key,
+					Detail:      fmt.Sprintf("%q: %v", f.Value, f.Err),
+				})
+			}
+		} else if err != nil {
+			problems = append(problems, model.ValidationProblem{
+				Kind:        model.ProblemInvalidValue,
+				PlayerIndex: idx,
+				Detail:      err.Error(),
+			})
+		}
+		players[idx] = player
+	}
+	// Enjambre es cooperativo y no tiene equipo rival
+	if r.GameMode.Mode != model.GameModeSwarm {
+		problems = append(problems, layoutProblems(players)...)
+	}
+	problems = append(problems, duplicatePUUIDs(players)...)
+	return validationError(schema, problems)
+}
+
+// validationError devuelve un *model.ValidationError con los problemas, o nil si no hay ninguno
+func validationError(schema string, problems []model.ValidationProblem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &model.ValidationError{Schema: schema, Problems: problems}
+}
+
+// fieldProblems convierte los campos faltantes y extra de la validación por esquema en problemas
+func fieldProblems(playerIndex int, missing, extra []string) []model.ValidationProblem {
+	var problems []model.ValidationProblem
+	for _, key := range missing {
+		problems = append(problems, model.ValidationProblem{Kind: model.ProblemMissingField, PlayerIndex: playerIndex, Key: key})
+	}
+	for _, key := range extra {
+		problems = append(problems, model.ValidationProblem{Kind: model.ProblemExtraField, PlayerIndex: playerIndex, Key: key})
+	}
+	return problems
+}
+
+// layoutProblems comprueba que el número de jugadores y su reparto encajan en alguna cola: en
+// Arena (PLAYER_SUBTEAM distinto de cero) todas las parejas deben tener el mismo tamaño y en el
+// resto los dos lados deben tener los mismos jugadores, como mucho cinco
+func layoutProblems(players []model.PlayerStats) []model.ValidationProblem {
+	problem := func(format string, args ...any) []model.ValidationProblem {
+		return []model.ValidationProblem{{Kind: model.ProblemPlayerCount, PlayerIndex: -1, Detail: fmt.Sprintf(format, args...)}}
+	}
+	if len(players) == 0 {
+		return problem("la partida no tiene jugadores")
+	}
+
+	subteams := map[int]int{}
+	for _, p := range players {
+		if p.PlayerSubteam > 0 {
+			subteams[p.PlayerSubteam]++
+		}
+	}
+	if len(subteams) > 0 {
+		if withSubteam := sumCounts(subteams); withSubteam != len(players) {
+			return problem("%d de %d jugadores no tienen subequipo", len(players)-withSubteam, len(players))
+		}
+		sizes := map[int]bool{}
+		for _, n := range subteams {
+			sizes[n] = true
+		}
+		if len(sizes) > 1 {
+			return problem("subequipos de distinto tamaño: %v", sortedCounts(subteams))
+		}
+		return nil
+	}
+
+	teams := map[model.Team]int{}
+	for _, p := range players {
+		teams[p.Team]++
+	}
+	switch {
+	case teams[model.TeamUnknown] > 0:
+		return problem("%d jugadores sin equipo azul o rojo", teams[model.TeamUnknown])
+	case teams[model.TeamBlue] != teams[model.TeamRed]:
+		return problem("equipos desiguales: %d en azul y %d en rojo", teams[model.TeamBlue], teams[model.TeamRed])
+	case teams[model.TeamBlue] > maxTeamSize:
+		return problem("%d jugadores por equipo, más de %d", teams[model.TeamBlue], maxTeamSize)
+	}
+	return nil
+}
+
+// duplicatePUUIDs devuelve un problema por cada jugador cuyo PUUID ya tenía un jugador anterior.
+// Los PUUID vacíos (parches antiguos, bots) no se comparan.
+func duplicatePUUIDs(players []model.PlayerStats) []model.ValidationProblem {
+	var problems []model.ValidationProblem
+	first := map[string]int{}
+	for idx, p := range players {
+		if p.PUUID == "" {
+			continue
+		}
+		if prev, ok := first[p.PUUID]; ok {
+			problems = append(problems, model.ValidationProblem{
+				Kind:        model.ProblemDuplicatePUUID,
+				PlayerIndex: idx,
+				Key:         "PUUID",
+				Detail:      fmt.Sprintf("mismo PUUID que el jugador %d", prev),
+			})
+			continue
+		}
+		first[p.PUUID] = idx
+	}
+	return problems
+}
+
+// sumCounts suma los jugadores de todos los grupos
+func sumCounts(counts map[int]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// sortedCounts devuelve el tamaño de cada grupo ordenado por su identificador, para mensajes estables
+func sortedCounts(counts map[int]int) []int {
+	ids := make([]int, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	sizes := make([]int, len(ids))
+	for i, id := range ids {
+		sizes[i] = counts[id]
+	}
+	return sizes
+}
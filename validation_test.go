@@ -0,0 +1,46 @@
+package roflparser
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+func TestStrictValidationNonStringStats(t *testing.T) {
+	metadata := `{"gameLength":1800000,"gameVersion":"","lastGameChunkId":1,"lastKeyFrameId":1,` +
+		`"statsJson":"[{\"PUUID\":\"a\",\"TEAM\":\"100\",\"GOLD_EARNED\":1500},{\"PUUID\":\"b\",\"TEAM\":\"200\",\"GOLD_EARNED\":\"900\"}]"}`
+	_, _, _, err := Parse(bytes.NewReader(encodeTestReplay(t, metadata)), WithStrictValidation())
+
+	var valErr *model.ValidationError
+	if !errors.As(err, &valErr) {
+		t.Fatalf("se esperaba un *model.ValidationError: %v", err)
+	}
+	if valErr.Has(model.ProblemPlayerCount) {
+		t.Fatalf("los jugadores deben revisarse aunque haya valores que no son strings: %v", valErr.Problems)
+	}
+	found := false
+	for _, p := range valErr.Problems {
+		if p.Kind == model.ProblemInvalidValue && p.PlayerIndex == 0 && p.Key == "GOLD_EARNED" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("falta el problema invalidValue de GOLD_EARNED: %v", valErr.Problems)
+	}
+}
+
+func TestStrictValidationWithoutSchema(t *testing.T) {
+	metadata := `{"gameLength":1800000,"gameVersion":"99.1.1.1","lastGameChunkId":1,"lastKeyFrameId":1,"newKey":1,` +
+		`"statsJson":"[{\"PUUID\":\"a\",\"TEAM\":\"100\"},{\"PUUID\":\"b\",\"TEAM\":\"200\"}]"}`
+	_, metaErr, _, err := Parse(bytes.NewReader(encodeTestReplay(t, metadata)), WithStrictValidation())
+	if len(metaErr.ExtraFields) == 0 {
+		t.Fatalf("MetadataValidationError debe seguir informando de las diferencias: %+v", metaErr)
+	}
+
+	var valErr *model.ValidationError
+	if errors.As(err, &valErr) && (valErr.Has(model.ProblemMissingField) || valErr.Has(model.ProblemExtraField)) {
+		t.Fatalf("sin esquema para el parche no se deben exigir campos: %v", valErr.Problems)
+	}
+}
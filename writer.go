@@ -0,0 +1,235 @@
+package roflparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// magicV1 es el magic que se escribe cuando el Rofl no trae uno propio
+var magicV1 = [6]byte{'R', 'I', 'O', 'T', 0x00, 0x00}
+
+// ErrPayloadSkipped indica que Encode recibió un Rofl cuyo payload no se leyó al parsearlo, así que
+// el archivo escrito perdería todos los chunks y keyframes del original
+var ErrPayloadSkipped = errors.New("el payload del replay no se leyó")
+
+// Encode serializa r como un archivo .rofl v1 y devuelve los bytes escritos. La cabecera,
+// model.Lengths y los contadores del payload header se recalculan a partir del contenido, la
+// metadata se escribe desde Metadata con StatsJSON como fuente de las estadísticas (conservando
+// las claves de RawMetadata sin campo propio) y los datos de chunks y keyframes se copian byte a
+// byte. La firma se copia tal cual, así que deja de ser válida si se modificó el contenido. Los
+// archivos ROFL2 no se pueden escribir, y si el payload no se leyó (r.PayloadSkipped) se devuelve
+// ErrPayloadSkipped en lugar de escribir un replay sin segmentos.
+func Encode(w io.Writer, r *model.Rofl) (int64, error) {
+	if r.FormatVersion == model.FormatV2 {
+		return 0, fmt.Errorf("%w: la escritura de %s no está soportada", ErrUnsupportedFormat, r.FormatVersion)
+	}
+	if r.PayloadSkipped {
+		return 0, fmt.Errorf("%w: vuelve a parsearlo sin WithMetadataOnly o pon PayloadSkipped a false para escribirlo sin payload", ErrPayloadSkipped)
+	}
+	magic := r.Magic
+	if r.FormatVersion == model.FormatUnknown {
+		magic = magicV1
+	}
+
+	metaBytes, err := encodeMetadata(r)
+	if err != nil {
+		return 0, err
+	}
+	payloadHeader := encodePayloadHeader(r)
+
+	// --- Calcular el índice de segmentos ---
+	count := len(r.Chunks) + len(r.Keyframes)
+	index := make([]segmentHeader, 0, count)
+	var dataLength int64
+	addSegment := func(id uint32, segmentType byte, length int, nextId uint32) {
+		index = append(index, segmentHeader{
+			Id:     id,
+			Type:   segmentType,
+			Length: uint32(length),
+			NextId: nextId,
+			Offset: uint32(dataLength),
+		})
+		dataLength += int64(length)
+	}
+	for _, c := range r.Chunks {
+		addSegment(c.Id, model.SegmentTypeChunk, segmentLength(c.Data, c.Length), c.NextId)
+	}
+	for _, k := range r.Keyframes {
+		addSegment(k.Id, model.SegmentTypeKeyframe, segmentLength(k.Data, k.Length), k.NextId)
+	}
+
+	// --- Recalcular longitudes y offsets ---
+	payloadOffset := int64(headerSize) + int64(len(metaBytes)) + int64(len(payloadHeader))
+	fileSize := payloadOffset + int64(count)*segmentHeaderSize + dataLength
+	if fileSize > math.MaxUint32 {
+		return 0, fmt.Errorf("el archivo resultante ocupa %d bytes y no cabe en los offsets de 32 bits", fileSize)
+	}
+	lengths := model.Lengths{
+		Header:              headerSize,
+		File:                uint32(fileSize),
+		MetadataOffset:      headerSize,
+		Metadata:            uint32(len(metaBytes)),
+		PayloadHeaderOffset: uint32(headerSize + len(metaBytes)),
+		PayloadHeader:       uint32(len(payloadHeader)),
+		PayloadOffset:       uint32(payloadOffset),
+	}
+
+	// --- Escribir el archivo ---
+	cw := &countingWriter{w: w}
+	fields := []any{magic, r.Signature, lengths}
+	for _, field := range fields {
+		if err := binary.Write(cw, binary.LittleEndian, field); err != nil {
+			return cw.n, fmt.Errorf("error escribiendo cabecera: %w", err)
+		}
+	}
+	if _, err := cw.Write(metaBytes); err != nil {
+		return cw.n, fmt.Errorf("error escribiendo metadata: %w", err)
+	}
+	if _, err := cw.Write(payloadHeader); err != nil {
+		return cw.n, fmt.Errorf("error escribiendo payload header: %w", err)
+	}
+	if err := binary.Write(cw, binary.LittleEndian, index); err != nil {
+		return cw.n, fmt.Errorf("error escribiendo índice de segmentos: %w", err)
+	}
+	for _, c := range r.Chunks {
+		data, err := r.ChunkData(c)
+		if err != nil {
+			return cw.n, fmt.Errorf("chunk %d: %w", c.Id, err)
+		}
+		if _, err := cw.Write(data); err != nil {
+			return cw.n, fmt.Errorf("error escribiendo chunk %d: %w", c.Id, err)
+		}
+	}
+	for _, k := range r.Keyframes {
+		data, err := r.KeyframeData(k)
+		if err != nil {
+			return cw.n, fmt.Errorf("keyframe %d: %w", k.Id, err)
+		}
+		if _, err := cw.Write(data); err != nil {
+			return cw.n, fmt.Errorf("error escribiendo keyframe %d: %w", k.Id, err)
+		}
+	}
+	return cw.n, nil
+}
+
+// WriteFile serializa r con Encode en el archivo de la ruta dada
+func WriteFile(path string, r *model.Rofl) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creando archivo: %w", err)
+	}
+	bw := bufio.NewWriter(file)
+	if _, err := Encode(bw, r); err != nil {
+		file.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("error escribiendo archivo: %w", err)
+	}
+	return file.Close()
+}
+
+// encodeMetadata serializa la metadata. Si Metadata no cambió desde el parseo se escribe
+// RawMetadata tal cual; si cambió, los campos de Metadata se escriben sobre las claves de
+// RawMetadata, de modo que las claves sin campo en model.MetadataJson no se pierden. Stats se
+// deriva de StatsJSON al leer, así que no se escribe.
+func encodeMetadata(r *model.Rofl) ([]byte, error) {
+	meta := r.Metadata
+	meta.Stats = nil
+	if len(r.RawMetadata) == 0 {
+		return marshalMetadata(meta)
+	}
+
+	var original model.MetadataJson
+	if err := json.Unmarshal(r.RawMetadata, &original); err == nil {
+		original.Stats = nil
+		if reflect.DeepEqual(original, meta) {
+			return r.RawMetadata, nil
+		}
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(r.RawMetadata, &fields); err != nil {
+		return nil, fmt.Errorf("error decodificando RawMetadata: %w", err)
+	}
+	known, err := marshalMetadata(meta)
+	if err != nil {
+		return nil, err
+	}
+	var knownFields map[string]json.RawMessage
+	if err := json.Unmarshal(known, &knownFields); err != nil {
+		return nil, fmt.Errorf("error serializando metadata: %w", err)
+	}
+	for key, value := range knownFields {
+		fields[key] = value
+	}
+	return marshalMetadata(fields)
+}
+
+// marshalMetadata serializa v en JSON sin escapar HTML ni añadir salto de línea final
+func marshalMetadata(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("error serializando metadata: %w", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// encodePayloadHeader serializa el payload header con los contadores y la longitud de la clave
+// recalculados a partir de los segmentos y la clave de r
+func encodePayloadHeader(r *model.Rofl) []byte {
+	ph := r.PayloadHeader
+	ph.ChunkCount = uint32(len(r.Chunks))
+	ph.KeyframeCount = uint32(len(r.Keyframes))
+	ph.EncryptionKeyLength = uint16(len(ph.EncryptionKey))
+
+	var buf bytes.Buffer
+	fields := []any{
+		ph.GameId,
+		ph.GameLength,
+		ph.KeyframeCount,
+		ph.ChunkCount,
+		ph.EndStartupChunkId,
+		ph.StartGameChunkId,
+		ph.KeyframeInterval,
+		ph.EncryptionKeyLength,
+	}
+	for _, field := range fields {
+		binary.Write(&buf, binary.LittleEndian, field)
+	}
+	buf.WriteString(ph.EncryptionKey)
+	return buf.Bytes()
+}
+
+// segmentLength devuelve la longitud de un segmento: la de sus datos si están cargados
+// (pueden haberse modificado) o la del índice original si no
+func segmentLength(data []byte, length uint32) int {
+	if data != nil {
+		return len(data)
+	}
+	return int(length)
+}
+
+// countingWriter cuenta los bytes escritos en w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,103 @@
+package roflparser
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/pointedsec/rofl-parser/model"
+)
+
+// testMetadata es un bloque de metadata con una clave que no tiene campo en model.MetadataJson
+const testMetadata = `{"gameLength":1800000,"gameVersion":"13.3.1","lastGameChunkId":1,"lastKeyFrameId":1,` +
+	`"statsJson":"[{\"NAME\":\"a\",\"TEAM\":\"100\"}]","newKey":{"nested":[1,2]}}`
+
+// encodeTestReplay escribe un replay v1 con la metadata dada, un chunk y un keyframe
+func encodeTestReplay(t *testing.T, metadata string) []byte {
+	t.Helper()
+	r := &model.Rofl{
+		RawMetadata:   []byte(metadata),
+		PayloadHeader: model.PayloadHeader{GameId: 7270309237, EncryptionKey: "a2V5"},
+		Chunks:        []model.Chunk{{Id: 1, Data: []byte("chunk")}},
+		Keyframes:     []model.Keyframe{{Id: 1, Data: []byte("keyframe")}},
+	}
+	if err := ParseJSON(metadata, &r.Metadata); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := Encode(&buf, r); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	first := encodeTestReplay(t, testMetadata)
+
+	r, metaErr, _, err := Parse(bytes.NewReader(first))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !slices.Contains(metaErr.ExtraFields, "newKey") {
+		t.Fatalf("ExtraFields = %v, se esperaba newKey", metaErr.ExtraFields)
+	}
+	if string(r.RawMetadata) != testMetadata {
+		t.Fatalf("RawMetadata = %s", r.RawMetadata)
+	}
+
+	var second bytes.Buffer
+	if _, err := Encode(&second, r); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !bytes.Equal(first, second.Bytes()) {
+		t.Fatal("parsear y volver a escribir cambia el archivo")
+	}
+}
+
+func TestEncodeModifiedMetadataKeepsUnknownKeys(t *testing.T) {
+	r, _, _, err := Parse(bytes.NewReader(encodeTestReplay(t, testMetadata)))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	r.Metadata.GameVersion = "13.3.2"
+
+	var buf bytes.Buffer
+	if _, err := Encode(&buf, r); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	back, metaErr, _, err := Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if back.Metadata.GameVersion != "13.3.2" {
+		t.Fatalf("GameVersion = %q, se esperaba 13.3.2", back.Metadata.GameVersion)
+	}
+	if !slices.Contains(metaErr.ExtraFields, "newKey") {
+		t.Fatalf("ExtraFields = %v, se esperaba newKey", metaErr.ExtraFields)
+	}
+	if !bytes.Contains(back.RawMetadata, []byte(`"newKey":{"nested":[1,2]}`)) {
+		t.Fatalf("RawMetadata no conserva newKey: %s", back.RawMetadata)
+	}
+	if len(back.Chunks) != 1 || string(back.Chunks[0].Data) != "chunk" {
+		t.Fatalf("chunks = %+v", back.Chunks)
+	}
+}
+
+func TestEncodeMetadataOnlyReplay(t *testing.T) {
+	r, _, _, err := Parse(bytes.NewReader(encodeTestReplay(t, testMetadata)), WithMetadataOnly())
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if _, err := Encode(&bytes.Buffer{}, r); !errors.Is(err, ErrPayloadSkipped) {
+		t.Fatalf("Encode de un replay sin payload leído = %v, se esperaba ErrPayloadSkipped", err)
+	}
+
+	// Anonymize descarta el payload a propósito
+	if err := Anonymize(r, []byte("salt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Encode(&bytes.Buffer{}, r); err != nil {
+		t.Fatalf("Encode tras Anonymize: %v", err)
+	}
+}